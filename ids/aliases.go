@@ -5,24 +5,35 @@ package ids
 
 import (
 	"fmt"
+	"sync"
 )
 
 // Aliaser allows one to give an ID aliases and lookup the aliases given to an
 // ID. An ID can have arbitrarily many aliases; two IDs may not have the same
-// alias.
+// alias. It is safe to use concurrently.
 type Aliaser struct {
+	lock    sync.RWMutex
 	dealias map[string]ID
 	aliases map[[32]byte][]string
+
+	// onChange, if non-nil, is called with an ID whenever its aliases change
+	onChange func(id ID)
 }
 
 // Initialize the aliaser to have no aliases
 func (a *Aliaser) Initialize() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
 	a.dealias = make(map[string]ID)
 	a.aliases = make(map[[32]byte][]string)
 }
 
 // Lookup returns the ID associated with alias
 func (a *Aliaser) Lookup(alias string) (ID, error) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
 	if ID, ok := a.dealias[alias]; ok {
 		return ID, nil
 	}
@@ -30,10 +41,22 @@ func (a *Aliaser) Lookup(alias string) (ID, error) {
 }
 
 // Aliases returns the aliases of an ID
-func (a Aliaser) Aliases(id ID) []string { return a.aliases[id.Key()] }
+func (a *Aliaser) Aliases(id ID) []string {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	aliases := a.aliases[id.Key()]
+	if aliases == nil {
+		return nil
+	}
+	return append([]string(nil), aliases...)
+}
 
 // PrimaryAlias returns the first alias of [id]
-func (a Aliaser) PrimaryAlias(id ID) (string, error) {
+func (a *Aliaser) PrimaryAlias(id ID) (string, error) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
 	aliases, exists := a.aliases[id.Key()]
 	if !exists || len(aliases) == 0 {
 		return "", fmt.Errorf("there is no alias for ID %s", id)
@@ -41,14 +64,131 @@ func (a Aliaser) PrimaryAlias(id ID) (string, error) {
 	return aliases[0], nil
 }
 
+// PrimaryAliasOrDefault returns the primary alias of [id] if it has one, and
+// [def] otherwise
+func (a *Aliaser) PrimaryAliasOrDefault(id ID, def string) string {
+	if alias, err := a.PrimaryAlias(id); err == nil {
+		return alias
+	}
+	return def
+}
+
+// Format returns the primary alias of [id] if it has one, and the string form
+// of [id] otherwise. This allows the aliaser to be used as the canonical
+// formatter of IDs.
+func (a *Aliaser) Format(id ID) string {
+	if alias, err := a.PrimaryAlias(id); err == nil {
+		return alias
+	}
+	return id.String()
+}
+
 // Alias gives [id] the alias [alias]
-func (a Aliaser) Alias(id ID, alias string) error {
+func (a *Aliaser) Alias(id ID, alias string) error {
+	a.lock.Lock()
 	if _, exists := a.dealias[alias]; exists {
+		a.lock.Unlock()
 		return fmt.Errorf("%s is already used as an alias for an ID", alias)
 	}
 	key := id.Key()
 
 	a.dealias[alias] = id
 	a.aliases[key] = append(a.aliases[key], alias)
+	a.lock.Unlock()
+
+	a.changed(id)
 	return nil
 }
+
+// SetPrimaryAlias makes [alias], which must already be an alias of [id], the
+// primary alias of [id]
+func (a *Aliaser) SetPrimaryAlias(id ID, alias string) error {
+	a.lock.Lock()
+	if aliasedID, exists := a.dealias[alias]; !exists || !aliasedID.Equals(id) {
+		a.lock.Unlock()
+		return fmt.Errorf("%s is not an alias of ID %s", alias, id)
+	}
+	key := id.Key()
+
+	aliases := a.aliases[key]
+	for i, existing := range aliases {
+		if existing == alias {
+			copy(aliases[1:i+1], aliases[:i])
+			aliases[0] = alias
+			break
+		}
+	}
+	a.lock.Unlock()
+
+	a.changed(id)
+	return nil
+}
+
+// RemoveAlias removes [alias] from the ID it was given to
+func (a *Aliaser) RemoveAlias(alias string) error {
+	a.lock.Lock()
+	id, exists := a.dealias[alias]
+	if !exists {
+		a.lock.Unlock()
+		return fmt.Errorf("there is no ID with alias %s", alias)
+	}
+	key := id.Key()
+
+	delete(a.dealias, alias)
+	aliases := a.aliases[key]
+	for i, existing := range aliases {
+		if existing == alias {
+			aliases = append(aliases[:i], aliases[i+1:]...)
+			break
+		}
+	}
+	if len(aliases) == 0 {
+		delete(a.aliases, key)
+	} else {
+		a.aliases[key] = aliases
+	}
+	a.lock.Unlock()
+
+	a.changed(id)
+	return nil
+}
+
+// RemoveID removes every alias given to [id]. If [id] has no aliases, this is
+// a no-op.
+func (a *Aliaser) RemoveID(id ID) {
+	a.lock.Lock()
+	key := id.Key()
+	aliases, exists := a.aliases[key]
+	if !exists {
+		a.lock.Unlock()
+		return
+	}
+
+	for _, alias := range aliases {
+		delete(a.dealias, alias)
+	}
+	delete(a.aliases, key)
+	a.lock.Unlock()
+
+	a.changed(id)
+}
+
+// OnAliasChange registers [f] to be called with an ID whenever the aliases of
+// that ID are modified. Passing nil removes the callback. The callback is
+// called without the aliaser being locked, so it may use the aliaser.
+func (a *Aliaser) OnAliasChange(f func(id ID)) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.onChange = f
+}
+
+func (a *Aliaser) changed(id ID) {
+	a.lock.RLock()
+	onChange := a.onChange
+	a.lock.RUnlock()
+
+	if onChange != nil {
+		onChange(id)
+	}
+}
@@ -4,6 +4,7 @@
 package ids
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -13,12 +14,12 @@ func TestAliaserLookupError(t *testing.T) {
 	emptyAliaser.Initialize()
 	tests := []struct {
 		label   string
-		aliaser Aliaser
+		aliaser *Aliaser
 		alias   string
 		res     ID
 	}{
-		{"Unitialized", Aliaser{}, "Batwoman", ID{}},
-		{"Empty", emptyAliaser, "Batman", ID{}},
+		{"Unitialized", &Aliaser{}, "Batwoman", ID{}},
+		{"Empty", &emptyAliaser, "Batman", ID{}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.label, func(t *testing.T) {
@@ -99,6 +100,22 @@ func TestAliaserPrimaryAlias(t *testing.T) {
 	}
 }
 
+func TestAliaserPrimaryAliasOrDefault(t *testing.T) {
+	id1 := NewID([32]byte{'J', 'a', 'm', 'e', 's', ' ', 'G', 'o', 'r', 'd', 'o', 'n'})
+	id2 := NewID([32]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	aliaser := Aliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id2, "Batman")
+	aliaser.Alias(id2, "Dark Knight")
+
+	if res := aliaser.PrimaryAliasOrDefault(id1, "Commissioner"); res != "Commissioner" {
+		t.Fatalf("Got %v, expected %v", res, "Commissioner")
+	}
+	if res := aliaser.PrimaryAliasOrDefault(id2, "Commissioner"); res != "Batman" {
+		t.Fatalf("Got %v, expected %v", res, "Batman")
+	}
+}
+
 func TestAliaserAliasClash(t *testing.T) {
 	id1 := NewID([32]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
 	id2 := NewID([32]byte{'D', 'i', 'c', 'k', ' ', 'G', 'r', 'a', 'y', 's', 'o', 'n'})
@@ -111,3 +128,220 @@ func TestAliaserAliasClash(t *testing.T) {
 		t.Fatalf("Expected an error, due to an existing alias")
 	}
 }
+
+func TestAliaserRemovePrimaryAlias(t *testing.T) {
+	id1 := NewID([32]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	id2 := NewID([32]byte{'T', 'e', 'r', 'r', 'y', ' ', 'M', 'c', 'G', 'i', 'n', 'n', 'i', 's'})
+	aliaser := Aliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id1, "Batman")
+	aliaser.Alias(id1, "Dark Knight")
+	aliaser.Alias(id1, "World's Greatest Detective")
+
+	if err := aliaser.RemoveAlias("Batman"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := aliaser.Lookup("Batman"); err == nil {
+		t.Fatal("Expected an error due to a removed alias")
+	}
+	expected := []string{"Dark Knight", "World's Greatest Detective"}
+	if aliases := aliaser.Aliases(id1); !reflect.DeepEqual(aliases, expected) {
+		t.Fatalf("Got %v, expected %v", aliases, expected)
+	}
+	if res, err := aliaser.PrimaryAlias(id1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	} else if res != "Dark Knight" {
+		t.Fatalf("Got %v, expected %v", res, "Dark Knight")
+	}
+
+	// The removed alias can be given to a different ID
+	if err := aliaser.Alias(id2, "Batman"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if res, err := aliaser.Lookup("Batman"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	} else if !id2.Equals(res) {
+		t.Fatalf("Got %v, expected %v", res, id2)
+	}
+}
+
+func TestAliaserRemoveNonPrimaryAlias(t *testing.T) {
+	id := NewID([32]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	aliaser := Aliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id, "Batman")
+	aliaser.Alias(id, "Dark Knight")
+	aliaser.Alias(id, "World's Greatest Detective")
+
+	if err := aliaser.RemoveAlias("Dark Knight"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := aliaser.Lookup("Dark Knight"); err == nil {
+		t.Fatal("Expected an error due to a removed alias")
+	}
+	expected := []string{"Batman", "World's Greatest Detective"}
+	if aliases := aliaser.Aliases(id); !reflect.DeepEqual(aliases, expected) {
+		t.Fatalf("Got %v, expected %v", aliases, expected)
+	}
+	if res, err := aliaser.PrimaryAlias(id); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	} else if res != "Batman" {
+		t.Fatalf("Got %v, expected %v", res, "Batman")
+	}
+
+	// Removing the last alias leaves the ID without any aliases
+	aliaser.RemoveAlias("Batman")
+	aliaser.RemoveAlias("World's Greatest Detective")
+	if aliases := aliaser.Aliases(id); len(aliases) != 0 {
+		t.Fatalf("Unexpected aliases %#v", aliases)
+	} else if _, err := aliaser.PrimaryAlias(id); err == nil {
+		t.Fatal("Expected an error given an id with no aliases")
+	}
+}
+
+func TestAliaserRemoveMissingAlias(t *testing.T) {
+	id := NewID([32]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	aliaser := Aliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id, "Batman")
+
+	if err := aliaser.RemoveAlias("Robin"); err == nil {
+		t.Fatal("Expected an error due to a missing alias")
+	}
+	expected := []string{"Batman"}
+	if aliases := aliaser.Aliases(id); !reflect.DeepEqual(aliases, expected) {
+		t.Fatalf("Got %v, expected %v", aliases, expected)
+	}
+}
+
+func TestAliaserRemoveID(t *testing.T) {
+	id1 := NewID([32]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	id2 := NewID([32]byte{'D', 'i', 'c', 'k', ' ', 'G', 'r', 'a', 'y', 's', 'o', 'n'})
+	aliaser := Aliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id1, "Batman")
+	aliaser.Alias(id1, "Dark Knight")
+	aliaser.Alias(id2, "Robin")
+
+	aliaser.RemoveID(id1)
+
+	for _, alias := range []string{"Batman", "Dark Knight"} {
+		if _, err := aliaser.Lookup(alias); err == nil {
+			t.Fatalf("Expected an error due to the removed alias %s", alias)
+		} else if expected := fmt.Sprintf("there is no ID with alias %s", alias); err.Error() != expected {
+			t.Fatalf("Got error %q, expected %q", err, expected)
+		}
+	}
+	if aliases := aliaser.Aliases(id1); len(aliases) != 0 {
+		t.Fatalf("Unexpected aliases %#v", aliases)
+	}
+
+	// The aliases of other IDs are unaffected
+	if res, err := aliaser.Lookup("Robin"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	} else if !id2.Equals(res) {
+		t.Fatalf("Got %v, expected %v", res, id2)
+	}
+
+	// Removing an ID without aliases is a no-op
+	aliaser.RemoveID(id1)
+	aliaser.RemoveID(NewID([32]byte{'J', 'o', 'k', 'e', 'r'}))
+
+	// The removed aliases can be given to a different ID
+	if err := aliaser.Alias(id2, "Batman"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestAliaserOnAliasChange(t *testing.T) {
+	id := NewID([32]byte{'S', 'e', 'l', 'i', 'n', 'a', ' ', 'K', 'y', 'l', 'e'})
+	aliaser := Aliaser{}
+	aliaser.Initialize()
+
+	changes := 0
+	aliaser.OnAliasChange(func(changed ID) {
+		if !changed.Equals(id) {
+			t.Fatalf("Got change for %v, expected %v", changed, id)
+		}
+		changes++
+	})
+
+	if err := aliaser.Alias(id, "Catwoman"); err != nil {
+		t.Fatal(err)
+	} else if changes != 1 {
+		t.Fatalf("Alias triggered %d changes, expected 1", changes)
+	}
+
+	if err := aliaser.Alias(id, "The Cat"); err != nil {
+		t.Fatal(err)
+	} else if changes != 2 {
+		t.Fatalf("Alias triggered %d changes, expected 2", changes)
+	}
+
+	if err := aliaser.SetPrimaryAlias(id, "The Cat"); err != nil {
+		t.Fatal(err)
+	} else if changes != 3 {
+		t.Fatalf("SetPrimaryAlias triggered %d changes, expected 3", changes)
+	} else if alias, _ := aliaser.PrimaryAlias(id); alias != "The Cat" {
+		t.Fatalf("Got primary alias %s, expected %s", alias, "The Cat")
+	}
+
+	if err := aliaser.RemoveAlias("Catwoman"); err != nil {
+		t.Fatal(err)
+	} else if changes != 4 {
+		t.Fatalf("RemoveAlias triggered %d changes, expected 4", changes)
+	}
+
+	if err := aliaser.Alias(id, "The Cat"); err == nil {
+		t.Fatal("Expected an error due to an existing alias")
+	} else if changes != 4 {
+		t.Fatalf("Failed Alias triggered a change")
+	}
+
+	aliaser.OnAliasChange(nil)
+	if err := aliaser.RemoveAlias("The Cat"); err != nil {
+		t.Fatal(err)
+	} else if changes != 4 {
+		t.Fatalf("Removed callback was called")
+	}
+}
+
+func TestAliaserFormat(t *testing.T) {
+	id1 := NewID([32]byte{'J', 'a', 'm', 'e', 's', ' ', 'G', 'o', 'r', 'd', 'o', 'n'})
+	id2 := NewID([32]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	aliaser := Aliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id2, "Batman")
+	aliaser.Alias(id2, "Dark Knight")
+
+	if formatted := aliaser.Format(id1); formatted != id1.String() {
+		t.Fatalf("Got %v, expected %v", formatted, id1.String())
+	}
+	if formatted := aliaser.Format(id2); formatted != "Batman" {
+		t.Fatalf("Got %v, expected %v", formatted, "Batman")
+	}
+}
+
+func TestAliaserConcurrentFormat(t *testing.T) {
+	id := NewID([32]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	aliaser := Aliaser{}
+	aliaser.Initialize()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			aliaser.Alias(id, fmt.Sprintf("alias%d", i))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		aliaser.Format(id)
+	}
+	<-done
+
+	if formatted := aliaser.Format(id); formatted != "alias0" {
+		t.Fatalf("Got %v, expected %v", formatted, "alias0")
+	}
+}
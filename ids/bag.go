@@ -4,10 +4,16 @@
 package ids
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 )
 
+var (
+	errMissingBagEntryID = errors.New("bag entry is missing an id")
+)
+
 // Bag is a multiset of IDs.
 //
 // A bag has the ability to split and filter on it's bits for ease of use for
@@ -71,6 +77,14 @@ func (b *Bag) AddCount(id ID, count int) {
 	}
 }
 
+// UnionBag adds all the ids from [other] to this bag, summing the counts of ids
+// that are in both bags.
+func (b *Bag) UnionBag(other Bag) {
+	for id, count := range other.counts {
+		b.AddCount(NewID(id), count)
+	}
+}
+
 // Count returns the number of times the id has been added.
 func (b *Bag) Count(id ID) int { return b.counts[*id.ID] }
 
@@ -134,6 +148,50 @@ func (b *Bag) Split(index uint) [2]Bag {
 	return splitVotes
 }
 
+// bagEntry is the JSON representation of an id in a bag
+type bagEntry struct {
+	ID    ID  `json:"id"`
+	Count int `json:"count"`
+}
+
+// MarshalJSON encodes the bag as a list of ids and their counts, sorted by id
+func (b Bag) MarshalJSON() ([]byte, error) {
+	idList := b.List()
+	SortIDs(idList)
+
+	entries := make([]bagEntry, len(idList))
+	for i, id := range idList {
+		entries[i] = bagEntry{
+			ID:    id,
+			Count: b.Count(id),
+		}
+	}
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON is the inverse of MarshalJSON. The threshold of the bag is
+// preserved.
+func (b *Bag) UnmarshalJSON(data []byte) error {
+	entries := []bagEntry(nil)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	newBag := Bag{}
+	newBag.SetThreshold(b.threshold)
+	for _, entry := range entries {
+		if entry.ID.IsZero() {
+			return errMissingBagEntryID
+		}
+		if entry.Count <= 0 {
+			return fmt.Errorf("bag entry for %s has count %d, expected a positive count", entry.ID, entry.Count)
+		}
+		newBag.AddCount(entry.ID, entry.Count)
+	}
+	*b = newBag
+	return nil
+}
+
 func (b *Bag) String() string {
 	sb := strings.Builder{}
 
@@ -4,6 +4,7 @@
 package ids
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -132,6 +133,34 @@ func TestBagSetThreshold(t *testing.T) {
 	}
 }
 
+func TestBagModeTie(t *testing.T) {
+	id0 := NewID([32]byte{0})
+	id1 := NewID([32]byte{1})
+
+	bag := Bag{}
+	bag.SetThreshold(2)
+
+	if mode, freq := bag.Mode(); !mode.IsZero() || freq != 0 {
+		t.Fatalf("Bag.Mode returned (%s, %d) expected (%s, %d)", mode, freq, ID{}, 0)
+	} else if threshold := bag.Threshold(); threshold.Len() != 0 {
+		t.Fatalf("Bag.Threshold returned %s expected %s", threshold, Set{})
+	}
+
+	bag.Add(id0, id1)
+	if mode, freq := bag.Mode(); !mode.Equals(id0) || freq != 1 {
+		t.Fatalf("Bag.Mode returned (%s, %d) expected (%s, %d)", mode, freq, id0, 1)
+	} else if threshold := bag.Threshold(); threshold.Len() != 0 {
+		t.Fatalf("Bag.Threshold returned %s expected %s", threshold, Set{})
+	}
+
+	bag.Add(id1, id0)
+	if mode, freq := bag.Mode(); !mode.Equals(id1) || freq != 2 {
+		t.Fatalf("Bag.Mode returned (%s, %d) expected (%s, %d)", mode, freq, id1, 2)
+	} else if threshold := bag.Threshold(); threshold.Len() != 2 || !threshold.Contains(id0) || !threshold.Contains(id1) {
+		t.Fatalf("Bag.Threshold returned %s expected both %s and %s", threshold, id0, id1)
+	}
+}
+
 func TestBagFilter(t *testing.T) {
 	id0 := Empty
 	id1 := NewID([32]byte{1})
@@ -199,3 +228,67 @@ func TestBagString(t *testing.T) {
 		t.Fatalf("Bag.String:\nReturned:\n%s\nExpected:\n%s", bagString, expected)
 	}
 }
+
+func TestBagUnionBag(t *testing.T) {
+	id0 := NewID([32]byte{0})
+	id1 := NewID([32]byte{1})
+	id2 := NewID([32]byte{2})
+
+	bag0 := Bag{}
+	bag0.SetThreshold(3)
+	bag0.AddCount(id0, 1)
+	bag0.AddCount(id1, 2)
+
+	bag1 := Bag{}
+	bag1.AddCount(id1, 3)
+	bag1.AddCount(id2, 1)
+
+	bag0.UnionBag(bag1)
+
+	if count := bag0.Count(id0); count != 1 {
+		t.Fatalf("Bag.Count returned %d expected %d", count, 1)
+	} else if count := bag0.Count(id1); count != 5 {
+		t.Fatalf("Bag.Count returned %d expected %d", count, 5)
+	} else if count := bag0.Count(id2); count != 1 {
+		t.Fatalf("Bag.Count returned %d expected %d", count, 1)
+	} else if size := bag0.Len(); size != 7 {
+		t.Fatalf("Bag.Len returned %d expected %d", size, 7)
+	} else if mode, freq := bag0.Mode(); !mode.Equals(id1) {
+		t.Fatalf("Bag.Mode[0] returned %s expected %s", mode, id1)
+	} else if freq != 5 {
+		t.Fatalf("Bag.Mode[1] returned %d expected %d", freq, 5)
+	} else if threshold := bag0.Threshold(); threshold.Len() != 1 || !threshold.Contains(id1) {
+		t.Fatalf("Bag.Threshold returned %s expected %s", threshold, id1)
+	} else if size := bag1.Len(); size != 4 {
+		t.Fatalf("Bag.Len of the merged bag changed to %d", size)
+	}
+}
+
+func TestBagJSON(t *testing.T) {
+	id0 := NewID([32]byte{0})
+	id1 := NewID([32]byte{1})
+
+	bag := Bag{}
+	bag.AddCount(id1, 3)
+	bag.AddCount(id0, 2)
+
+	b, err := json.Marshal(bag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := Bag{}
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if !parsed.Equals(bag) {
+		t.Fatalf("Bag changed during round trip:\n%s\n%s", &bag, &parsed)
+	} else if mode, freq := parsed.Mode(); !mode.Equals(id1) || freq != 3 {
+		t.Fatalf("Bag.Mode returned (%s, %d) expected (%s, %d)", mode, freq, id1, 3)
+	}
+
+	if err := json.Unmarshal([]byte(`[{"id":"`+id0.String()+`","count":0}]`), &parsed); err == nil {
+		t.Fatalf("Should have errored due to a non-positive count")
+	}
+}
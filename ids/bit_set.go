@@ -18,6 +18,17 @@ func (bs *BitSet) Add(i uint) { *bs |= 1 << i }
 // Union adds all the elements in [s] to this set
 func (bs *BitSet) Union(s BitSet) { *bs |= s }
 
+// UnionChecked adds all the elements in [s] to this set, unless [s] contains
+// an element >= [maxWidth], in which case an error is returned and this set is
+// left unmodified
+func (bs *BitSet) UnionChecked(s BitSet, maxWidth uint) error {
+	if maxWidth < 64 && s>>maxWidth != 0 {
+		return fmt.Errorf("bit set %s contains elements beyond the maximum width of %d", s, maxWidth)
+	}
+	bs.Union(s)
+	return nil
+}
+
 // Intersection takes the intersection of [s] with this set
 func (bs *BitSet) Intersection(s BitSet) { *bs &= s }
 
@@ -27,7 +38,8 @@ func (bs *BitSet) Difference(s BitSet) { *bs &^= s }
 // Remove [i] from the set of ints
 func (bs *BitSet) Remove(i uint) { *bs &^= 1 << i }
 
-// Clear removes all elements from this set
+// Clear removes all elements from this set in place, so that the set can be
+// reused without reallocating
 func (bs *BitSet) Clear() { *bs = 0 }
 
 // Contains returns true if [i] was previously added to this set
@@ -155,3 +155,52 @@ func TestBitSetString(t *testing.T) {
 		t.Fatalf("BitSet.String returned %s expected %s", bsString, expected)
 	}
 }
+
+func TestBitSetClearInPlace(t *testing.T) {
+	buffer := make([]BitSet, 2)
+	for i := uint(0); i < 64; i += 3 {
+		buffer[0].Add(i)
+		buffer[1].Add(i)
+	}
+
+	bs := &buffer[0]
+	bs.Clear()
+
+	if buffer[0].Len() != 0 {
+		t.Fatalf("Clear should have emptied the buffered set")
+	} else if buffer[1].Len() != 22 {
+		t.Fatalf("Clear shouldn't have modified other sets")
+	}
+
+	bs.Add(1)
+	if !buffer[0].Contains(1) || buffer[0].Len() != 1 {
+		t.Fatalf("Cleared set should be reusable")
+	}
+}
+
+func TestBitSetUnionChecked(t *testing.T) {
+	bs := BitSet(0)
+	bs.Add(0)
+
+	inRange := BitSet(0)
+	inRange.Add(2)
+	if err := bs.UnionChecked(inRange, 3); err != nil {
+		t.Fatal(err)
+	} else if !bs.Contains(0) || !bs.Contains(2) || bs.Len() != 2 {
+		t.Fatalf("Wrong set after in range union: %s", bs)
+	}
+
+	outOfRange := BitSet(0)
+	outOfRange.Add(1)
+	outOfRange.Add(3)
+	if err := bs.UnionChecked(outOfRange, 3); err == nil {
+		t.Fatalf("Should have errored on an out of range union")
+	} else if bs.Contains(1) || bs.Contains(3) || bs.Len() != 2 {
+		t.Fatalf("Set shouldn't have been modified by an out of range union: %s", bs)
+	}
+
+	outOfRange.Add(63)
+	if err := bs.UnionChecked(outOfRange, 64); err != nil {
+		t.Fatalf("Every element should be in range of a width of 64: %s", err)
+	}
+}
@@ -5,6 +5,8 @@ package ids
 
 import (
 	"bytes"
+	"crypto/subtle"
+	"encoding/binary"
 	"encoding/hex"
 	"sort"
 
@@ -33,6 +35,15 @@ func ToID(bytes []byte) (ID, error) {
 	return NewID(addrHash), err
 }
 
+// IDFromWords is the inverse of ID.Words()
+func IDFromWords(w [4]uint64) ID {
+	id := [32]byte{}
+	for i, word := range w {
+		binary.BigEndian.PutUint64(id[i*8:], word)
+	}
+	return NewID(id)
+}
+
 // FromString is the inverse of ID.String()
 func FromString(idStr string) (ID, error) {
 	cb58 := formatting.CB58{}
@@ -43,6 +54,23 @@ func FromString(idStr string) (ID, error) {
 	return ToID(cb58.Bytes)
 }
 
+// ParsePrefixedID parses an ID that may be prefixed by the alias of the chain
+// it belongs to, such as "X-" or "P-". The prefix must be a single uppercase
+// letter followed by a dash, and is returned without the dash. If [s] doesn't
+// start with such a prefix, the returned prefix is empty and the whole string
+// is parsed as the ID.
+func ParsePrefixedID(s string) (string, ID, error) {
+	prefix := ""
+	if len(s) >= 2 && s[0] >= 'A' && s[0] <= 'Z' && s[1] == '-' {
+		prefix, s = s[:1], s[2:]
+	}
+	id, err := FromString(s)
+	if err != nil {
+		return "", ID{}, err
+	}
+	return prefix, id, nil
+}
+
 // MarshalJSON ...
 func (id ID) MarshalJSON() ([]byte, error) {
 	if id.IsZero() {
@@ -100,6 +128,26 @@ func (id ID) Equals(oID ID) bool {
 		(id.ID != nil && oID.ID != nil && bytes.Equal(id.Bytes(), oID.Bytes()))
 }
 
+// EqualsConstantTime returns true if the ids have the same byte
+// representation. The time taken doesn't depend on the contents of the ids, so
+// it should be used when comparing against secret derived ids.
+func (id ID) EqualsConstantTime(oID ID) bool {
+	if id.ID == nil || oID.ID == nil {
+		return id.ID == oID.ID
+	}
+	return subtle.ConstantTimeCompare(id.Bytes(), oID.Bytes()) == 1
+}
+
+// Words returns the 32 byte hash as 4 big-endian words, so that the first word
+// holds the first 8 bytes of the hash.
+func (id ID) Words() [4]uint64 {
+	w := [4]uint64{}
+	for i := range w {
+		w[i] = binary.BigEndian.Uint64(id.ID[i*8:])
+	}
+	return w
+}
+
 // Bytes returns the 32 byte hash as a slice. It is assumed this slice is not
 // modified.
 func (id ID) Bytes() []byte { return id.ID[:] }
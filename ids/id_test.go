@@ -32,6 +32,33 @@ func TestID(t *testing.T) {
 	}
 }
 
+func TestIDEqualsConstantTime(t *testing.T) {
+	id0 := NewID([32]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 's'})
+	id1 := NewID([32]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 's'})
+	id2 := NewID([32]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 't'})
+
+	tests := []struct {
+		label string
+		a, b  ID
+	}{
+		{"Same", id0, id0},
+		{"Matching", id0, id1},
+		{"Differing", id0, id2},
+		{"Nil", id0, ID{}},
+		{"Both nil", ID{}, ID{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			if expected, got := tt.a.Equals(tt.b), tt.a.EqualsConstantTime(tt.b); expected != got {
+				t.Errorf("Got %v, expected %v", got, expected)
+			}
+			if expected, got := tt.b.Equals(tt.a), tt.b.EqualsConstantTime(tt.a); expected != got {
+				t.Errorf("Got %v, expected %v", got, expected)
+			}
+		})
+	}
+}
+
 func TestIDBit(t *testing.T) {
 	id0 := NewID([32]byte{1 << 0})
 	id1 := NewID([32]byte{1 << 1})
@@ -95,6 +122,59 @@ func TestIDFromStringError(t *testing.T) {
 	}
 }
 
+func TestParsePrefixedID(t *testing.T) {
+	id := NewID([32]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 's'})
+	idStr := id.String()
+
+	tests := []struct {
+		in     string
+		prefix string
+	}{
+		{idStr, ""},
+		{"X-" + idStr, "X"},
+		{"P-" + idStr, "P"},
+		{"C-" + idStr, "C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			prefix, parsedID, err := ParsePrefixedID(tt.in)
+			switch {
+			case err != nil:
+				t.Fatal(err)
+			case prefix != tt.prefix:
+				t.Fatalf("Expected prefix %q, got %q", tt.prefix, prefix)
+			case !parsedID.Equals(id):
+				t.Fatalf("Expected ID %s, got %s", id, parsedID)
+			}
+		})
+	}
+}
+
+func TestParsePrefixedIDError(t *testing.T) {
+	idStr := NewID([32]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 's'}).String()
+
+	tests := []struct {
+		in string
+	}{
+		{""},
+		{"X-"},
+		{"X-foo"},
+		{"x-" + idStr},
+		{"XY-" + idStr},
+		{"-" + idStr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			prefix, _, err := ParsePrefixedID(tt.in)
+			if err == nil {
+				t.Error("Unexpected success")
+			} else if prefix != "" {
+				t.Errorf("Unexpected prefix %q on failure", prefix)
+			}
+		})
+	}
+}
+
 func TestIDMarshalJSON(t *testing.T) {
 	tests := []struct {
 		label string
@@ -157,6 +237,29 @@ func TestIDHex(t *testing.T) {
 	}
 }
 
+func TestIDWords(t *testing.T) {
+	id := NewID([32]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 's', 31: 1})
+	expected := [4]uint64{0x617661206c616273, 0, 0, 1}
+	if words := id.Words(); words != expected {
+		t.Fatalf("got %x, expected %x", words, expected)
+	}
+
+	tests := []ID{
+		Empty,
+		NewID([32]byte{24}),
+		NewID([32]byte{0: 0xff, 8: 0xff, 16: 0xff, 24: 0xff, 31: 0xff}),
+		Empty.Prefix(1),
+		Empty.Prefix(2),
+	}
+	for _, id := range tests {
+		t.Run(id.String(), func(t *testing.T) {
+			if result := IDFromWords(id.Words()); !result.Equals(id) {
+				t.Errorf("got %s, expected %s", result, id)
+			}
+		})
+	}
+}
+
 func TestIDString(t *testing.T) {
 	tests := []struct {
 		label    string
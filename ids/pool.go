@@ -0,0 +1,52 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package ids
+
+import (
+	"sync"
+)
+
+var (
+	setPool = sync.Pool{New: func() interface{} { return Set{} }}
+	bagPool = sync.Pool{New: func() interface{} { return &Bag{} }}
+)
+
+// GetSet returns an empty set from a shared pool. The set should be returned
+// with PutSet once it is no longer needed, so that its memory can be reused.
+func GetSet() Set { return setPool.Get().(Set) }
+
+// PutSet empties [s] and returns it to the shared pool. [s] must not be used,
+// or referenced, after it is returned.
+func PutSet(s Set) {
+	if s == nil {
+		return
+	}
+	for id := range s {
+		delete(s, id)
+	}
+	setPool.Put(s)
+}
+
+// GetBag returns an empty bag from a shared pool. The bag should be returned
+// with PutBag once it is no longer needed, so that its memory can be reused.
+func GetBag() *Bag { return bagPool.Get().(*Bag) }
+
+// PutBag empties [b] and returns it to the shared pool. [b] must not be used,
+// or referenced, after it is returned.
+func PutBag(b *Bag) {
+	if b == nil {
+		return
+	}
+	for id := range b.counts {
+		delete(b.counts, id)
+	}
+	for id := range b.metThreshold {
+		delete(b.metThreshold, id)
+	}
+	b.size = 0
+	b.mode = ID{}
+	b.modeFreq = 0
+	b.threshold = 0
+	bagPool.Put(b)
+}
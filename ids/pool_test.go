@@ -0,0 +1,88 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package ids
+
+import (
+	"testing"
+)
+
+func TestSetPool(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		s := GetSet()
+		if s.Len() != 0 {
+			t.Fatalf("Pooled set has %d ids, expected %d", s.Len(), 0)
+		}
+		s.Add(Empty.Prefix(uint64(i)), Empty.Prefix(uint64(i+1)))
+		PutSet(s)
+	}
+}
+
+func TestBagPool(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		b := GetBag()
+		if b.Len() != 0 {
+			t.Fatalf("Pooled bag has %d ids, expected %d", b.Len(), 0)
+		} else if threshold := b.Threshold(); threshold.Len() != 0 {
+			t.Fatalf("Pooled bag has %d ids that met the threshold, expected %d", threshold.Len(), 0)
+		} else if mode, freq := b.Mode(); !mode.IsZero() || freq != 0 {
+			t.Fatalf("Pooled bag has mode %s with frequency %d", mode, freq)
+		}
+
+		id := Empty.Prefix(uint64(i))
+		b.SetThreshold(2)
+		b.AddCount(id, 2)
+		if threshold := b.Threshold(); b.Count(id) != 2 || !threshold.Contains(id) {
+			t.Fatalf("Pooled bag should be usable")
+		}
+		PutBag(b)
+	}
+}
+
+func BenchmarkSetPool(b *testing.B) {
+	idList := make([]ID, 64)
+	for i := range idList {
+		idList[i] = Empty.Prefix(uint64(i))
+	}
+
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			s := GetSet()
+			s.Add(idList...)
+			PutSet(s)
+		}
+	})
+	b.Run("Fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			s := Set{}
+			s.Add(idList...)
+		}
+	})
+}
+
+func BenchmarkBagPool(b *testing.B) {
+	idList := make([]ID, 64)
+	for i := range idList {
+		idList[i] = Empty.Prefix(uint64(i))
+	}
+
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			bag := GetBag()
+			bag.SetThreshold(1)
+			bag.Add(idList...)
+			PutBag(bag)
+		}
+	})
+	b.Run("Fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			bag := Bag{}
+			bag.SetThreshold(1)
+			bag.Add(idList...)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package ids
+
+import (
+	"math/rand"
+)
+
+// seededSample moves a sample of [n] of the [size] elements of a sorted list to
+// the front of the list, using [swap] to exchange elements. The sample only
+// depends on [seed] and the contents of the list. Returns the number of
+// elements sampled, which is at most [size].
+func seededSample(size, n int, seed uint64, swap func(i, j int)) int {
+	if n > size {
+		n = size
+	}
+	if n <= 0 {
+		return 0
+	}
+
+	rng := rand.New(rand.NewSource(int64(seed)))
+	for i := 0; i < n; i++ {
+		swap(i, i+rng.Intn(size-i))
+	}
+	return n
+}
@@ -4,7 +4,18 @@
 package ids
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"strings"
+
+	"github.com/ava-labs/gecko/utils/hashing"
+)
+
+var (
+	errBadSetBytesLen       = errors.New("set bytes length isn't a multiple of the ID length")
+	errBadCanonicalSetCount = errors.New("canonical set bytes don't match their count")
+	errUnsortedCanonicalSet = errors.New("canonical set ids aren't sorted and unique")
 )
 
 // Set is a set of IDs
@@ -65,9 +76,80 @@ func (ids *Set) Remove(idList ...ID) {
 	}
 }
 
-// Clear empties this set
+// RetainFunc removes, in place, every id in this set for which [keep] returns
+// false
+func (ids Set) RetainFunc(keep func(ID) bool) {
+	for id := range ids {
+		if !keep(NewID(id)) {
+			delete(ids, id)
+		}
+	}
+}
+
+// Clear empties this set. Unlike removing every id, the capacity this set grew
+// to is released, so a reused set that briefly held many ids doesn't retain
+// that capacity.
 func (ids *Set) Clear() { *ids = nil }
 
+// PopN removes and returns up to [n] arbitrary ids from this set
+func (ids *Set) PopN(n int) []ID {
+	if n > ids.Len() {
+		n = ids.Len()
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	idList := make([]ID, 0, n)
+	for id := range *ids {
+		if len(idList) == n {
+			break
+		}
+		idList = append(idList, NewID(id))
+		delete(*ids, id)
+	}
+	return idList
+}
+
+// CappedList returns up to [size] ids from this set, or every id if the set has
+// fewer than [size] ids. Which ids are returned, and their order, depends on
+// the iteration order of the set, so it is unspecified.
+func (ids Set) CappedList(size int) []ID {
+	if size > len(ids) {
+		size = len(ids)
+	}
+	if size <= 0 {
+		return nil
+	}
+
+	idList := make([]ID, 0, size)
+	for id := range ids {
+		if len(idList) == size {
+			break
+		}
+		idList = append(idList, NewID(id))
+	}
+	return idList
+}
+
+// SampleSeeded returns [n] ids sampled from this set, or every id if the set
+// has fewer than [n] ids. Unlike sampling by iterating over the set, the sample
+// only depends on [seed] and the ids in the set, so it can be reproduced.
+func (ids Set) SampleSeeded(n int, seed uint64) []ID {
+	idList := ids.SortedList()
+	n = seededSample(len(idList), n, seed, func(i, j int) {
+		idList[i], idList[j] = idList[j], idList[i]
+	})
+	return idList[:n]
+}
+
+// CappedListSeeded returns a list of length at most [size] in the same manner
+// as SampleSeeded, so the same seed and set always produce the same list. It
+// mirrors ShortSet.CappedListSeeded.
+func (ids Set) CappedListSeeded(size int, seed uint64) []ID {
+	return ids.SampleSeeded(size, seed)
+}
+
 // List converts this set into a list
 func (ids Set) List() []ID {
 	idList := []ID(nil)
@@ -77,6 +159,78 @@ func (ids Set) List() []ID {
 	return idList
 }
 
+// ToMap returns a snapshot of this set as a map keyed by ID. Later changes to
+// the set are not reflected in the map, and vice versa. Because IDs compare by
+// pointer, the map should be iterated over rather than indexed with IDs that
+// were created separately.
+func (ids Set) ToMap() map[ID]struct{} {
+	idMap := make(map[ID]struct{}, len(ids))
+	for id := range ids {
+		idMap[NewID(id)] = struct{}{}
+	}
+	return idMap
+}
+
+// SortedList converts this set into a list sorted by the bytes of the ids
+func (ids Set) SortedList() []ID {
+	idList := ids.List()
+	SortIDs(idList)
+	return idList
+}
+
+// Bytes returns the concatenation of the ids in this set, sorted by their bytes
+func (ids Set) Bytes() []byte {
+	idList := ids.SortedList()
+	b := make([]byte, 0, len(idList)*hashing.HashLen)
+	for _, id := range idList {
+		b = append(b, id.Bytes()...)
+	}
+	return b
+}
+
+// CanonicalBytes returns the varint encoded number of ids in this set, followed
+// by the concatenation of the ids, sorted by their bytes. Unlike Bytes, the
+// number of ids is part of the encoding, so it is authenticated when the
+// encoding is hashed.
+func (ids Set) CanonicalBytes() []byte {
+	idList := ids.SortedList()
+	b := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(idList)*hashing.HashLen)
+	b = b[:binary.PutUvarint(b, uint64(len(idList)))]
+	for _, id := range idList {
+		b = append(b, id.Bytes()...)
+	}
+	return b
+}
+
+// SetFromCanonicalBytes is the inverse of Set.CanonicalBytes. An error is
+// returned if [b] isn't the canonical encoding of a set.
+func SetFromCanonicalBytes(b []byte) (Set, error) {
+	count, n := binary.Uvarint(b)
+	varint := [binary.MaxVarintLen64]byte{}
+	if n <= 0 || binary.PutUvarint(varint[:], count) != n {
+		// The count is missing, or isn't minimally encoded
+		return nil, errBadCanonicalSetCount
+	}
+	b = b[n:]
+	if count > uint64(len(b)/hashing.HashLen) || uint64(len(b)) != count*hashing.HashLen {
+		return nil, errBadCanonicalSetCount
+	}
+
+	ids := make(Set, count)
+	for i := 0; i < len(b); i += hashing.HashLen {
+		if i > 0 && bytes.Compare(b[i-hashing.HashLen:i], b[i:i+hashing.HashLen]) >= 0 {
+			return nil, errUnsortedCanonicalSet
+		}
+		id := [32]byte{}
+		copy(id[:], b[i:])
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+// Hash returns the hash of the bytes of this set
+func (ids Set) Hash() ID { return NewID(hashing.ComputeHash256Array(ids.Bytes())) }
+
 // Equals returns true if the sets contain the same elements
 func (ids Set) Equals(oIDs Set) bool {
 	if ids.Len() != oIDs.Len() {
@@ -90,18 +244,47 @@ func (ids Set) Equals(oIDs Set) bool {
 	return true
 }
 
+// Difference returns a new set of the ids in this set that aren't in [oIDs]
+func (ids Set) Difference(oIDs Set) Set {
+	diff := Set(nil)
+	for key := range ids {
+		if !oIDs[key] {
+			diff.Add(NewID(key))
+		}
+	}
+	return diff
+}
+
 // String returns the string representation of a set
 func (ids Set) String() string {
 	sb := strings.Builder{}
 	sb.WriteString("{")
-	first := true
-	for idBytes := range ids {
-		if !first {
+	for i, id := range ids.SortedList() {
+		if i != 0 {
 			sb.WriteString(", ")
 		}
-		first = false
-		sb.WriteString(NewID(idBytes).String())
+		sb.WriteString(id.String())
 	}
 	sb.WriteString("}")
 	return sb.String()
 }
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. The set is
+// encoded as the concatenation of its ids, sorted by their bytes.
+func (ids Set) MarshalBinary() ([]byte, error) { return ids.Bytes(), nil }
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (ids *Set) UnmarshalBinary(b []byte) error {
+	if len(b)%hashing.HashLen != 0 {
+		return errBadSetBytesLen
+	}
+
+	ids.Clear()
+	ids.init(len(b) / hashing.HashLen)
+	for i := 0; i < len(b); i += hashing.HashLen {
+		id := [32]byte{}
+		copy(id[:], b[i:])
+		(*ids)[id] = true
+	}
+	return nil
+}
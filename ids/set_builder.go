@@ -0,0 +1,45 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package ids
+
+// SetBuilder applies a sequence of set operations to a single set, so that no
+// intermediate sets are allocated. The zero value builds the empty set.
+type SetBuilder struct{ set Set }
+
+// NewSetBuilder returns a builder that starts with a copy of [initial]
+func NewSetBuilder(initial Set) *SetBuilder {
+	b := &SetBuilder{}
+	return b.Union(initial)
+}
+
+// Union adds all the ids in [set] to the built set
+func (b *SetBuilder) Union(set Set) *SetBuilder {
+	b.set.Union(set)
+	return b
+}
+
+// Difference removes all the ids in [set] from the built set
+func (b *SetBuilder) Difference(set Set) *SetBuilder {
+	for id := range set {
+		delete(b.set, id)
+	}
+	return b
+}
+
+// Intersection removes all the ids that aren't in [set] from the built set
+func (b *SetBuilder) Intersection(set Set) *SetBuilder {
+	for id := range b.set {
+		if !set[id] {
+			delete(b.set, id)
+		}
+	}
+	return b
+}
+
+// Build returns the built set. The builder must not be used afterwards.
+func (b *SetBuilder) Build() Set {
+	set := b.set
+	b.set = nil
+	return set
+}
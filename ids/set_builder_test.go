@@ -0,0 +1,52 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package ids
+
+import (
+	"testing"
+)
+
+func TestSetBuilder(t *testing.T) {
+	id0 := NewID([32]byte{0})
+	id1 := NewID([32]byte{1})
+	id2 := NewID([32]byte{2})
+	id3 := NewID([32]byte{3})
+
+	s0 := Set{}
+	s0.Add(id0, id1)
+	s1 := Set{}
+	s1.Add(id2, id3)
+	s2 := Set{}
+	s2.Add(id1)
+	s3 := Set{}
+	s3.Add(id0, id2, id3)
+
+	built := NewSetBuilder(s0).
+		Union(s1).
+		Difference(s2).
+		Intersection(s3).
+		Build()
+
+	expected := Set{}
+	for id := range s0 {
+		expected.Add(NewID(id))
+	}
+	expected.Union(s1)
+	expected.Remove(s2.List()...)
+	for _, id := range expected.List() {
+		if !s3.Contains(id) {
+			expected.Remove(id)
+		}
+	}
+
+	if !built.Equals(expected) {
+		t.Fatalf("Built set %s should equal %s", built, expected)
+	} else if s0.Len() != 2 || !s0.Contains(id0) || !s0.Contains(id1) {
+		t.Fatalf("Initial set shouldn't have been modified")
+	}
+
+	if empty := (&SetBuilder{}).Difference(s0).Intersection(s1).Build(); empty.Len() != 0 {
+		t.Fatalf("Zero builder should build the empty set, built %s", empty)
+	}
+}
@@ -4,6 +4,8 @@
 package ids
 
 import (
+	"bytes"
+	"encoding/gob"
 	"testing"
 )
 
@@ -55,3 +57,440 @@ func TestSet(t *testing.T) {
 		t.Fatalf("Sets overlap")
 	}
 }
+
+func TestSetPopN(t *testing.T) {
+	ids := Set{}
+	for i := 0; i < 10; i++ {
+		ids.Add(NewID([32]byte{byte(i)}))
+	}
+
+	popped := Set{}
+	for ids.Len() > 0 {
+		batch := ids.PopN(3)
+		if len(batch) == 0 || len(batch) > 3 {
+			t.Fatalf("PopN returned %d ids, expected between 1 and 3", len(batch))
+		}
+		for _, id := range batch {
+			if popped.Contains(id) {
+				t.Fatalf("PopN returned %s twice", id)
+			} else if ids.Contains(id) {
+				t.Fatalf("PopN didn't remove %s", id)
+			}
+			popped.Add(id)
+		}
+	}
+
+	if popped.Len() != 10 {
+		t.Fatalf("Drained %d ids, expected %d", popped.Len(), 10)
+	} else if batch := ids.PopN(3); batch != nil {
+		t.Fatalf("PopN on an empty set returned %v", batch)
+	}
+}
+
+func TestSetRetainFunc(t *testing.T) {
+	ids := Set{}
+	for i := 0; i < 10; i++ {
+		ids.Add(NewID([32]byte{byte(i)}))
+	}
+
+	ids.RetainFunc(func(id ID) bool { return id.Key()[0]%2 == 0 })
+
+	if ids.Len() != 5 {
+		t.Fatalf("Retained %d ids, expected %d", ids.Len(), 5)
+	}
+	for i := 0; i < 10; i++ {
+		id := NewID([32]byte{byte(i)})
+		if contains := ids.Contains(id); contains != (i%2 == 0) {
+			t.Fatalf("Contains(%s) = %t after retaining the even ids", id, contains)
+		}
+	}
+
+	ids.RetainFunc(func(ID) bool { return false })
+	if ids.Len() != 0 {
+		t.Fatalf("Retained %d ids, expected %d", ids.Len(), 0)
+	}
+}
+
+func TestSetBinaryGob(t *testing.T) {
+	type wrapper struct {
+		Name string
+		IDs  Set
+	}
+
+	original := wrapper{Name: "frontier"}
+	original.IDs.Add(
+		NewID([32]byte{2}),
+		NewID([32]byte{1}),
+		NewID([32]byte{3}),
+	)
+
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(original); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := wrapper{}
+	if err := gob.NewDecoder(&buf).Decode(&parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Name != original.Name {
+		t.Fatalf("Got name %s, expected %s", parsed.Name, original.Name)
+	} else if !parsed.IDs.Equals(original.IDs) {
+		t.Fatalf("Got set %s, expected %s", parsed.IDs, original.IDs)
+	}
+
+	b, err := original.IDs.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(b) != 3*32 {
+		t.Fatalf("Got %d bytes, expected %d", len(b), 3*32)
+	} else if b[0] != 1 || b[32] != 2 || b[64] != 3 {
+		t.Fatalf("Set bytes aren't sorted")
+	}
+
+	if err := (&Set{}).UnmarshalBinary(b[1:]); err == nil {
+		t.Fatalf("Should have errored due to a bad length")
+	}
+}
+
+func TestSetCanonicalBytes(t *testing.T) {
+	empty := Set{}
+	b := empty.CanonicalBytes()
+	if !bytes.Equal(b, []byte{0}) {
+		t.Fatalf("Got %v, expected the empty set to encode as a zero count", b)
+	}
+	if parsed, err := SetFromCanonicalBytes(b); err != nil {
+		t.Fatal(err)
+	} else if parsed.Len() != 0 {
+		t.Fatalf("Got set %s, expected the empty set", parsed)
+	}
+
+	set := Set{}
+	set.Add(
+		NewID([32]byte{2}),
+		NewID([32]byte{1}),
+		NewID([32]byte{3}),
+	)
+	b = set.CanonicalBytes()
+	if len(b) != 1+3*32 {
+		t.Fatalf("Got %d bytes, expected %d", len(b), 1+3*32)
+	} else if b[0] != 3 {
+		t.Fatalf("Got count %d, expected %d", b[0], 3)
+	} else if !bytes.Equal(b[1:], set.Bytes()) {
+		t.Fatalf("Canonical ids should be sorted")
+	}
+
+	parsed, err := SetFromCanonicalBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	} else if !parsed.Equals(set) {
+		t.Fatalf("Got set %s, expected %s", parsed, set)
+	}
+
+	unsorted := append([]byte{2}, NewID([32]byte{2}).Bytes()...)
+	unsorted = append(unsorted, NewID([32]byte{1}).Bytes()...)
+	duplicated := append([]byte{2}, NewID([32]byte{1}).Bytes()...)
+	duplicated = append(duplicated, NewID([32]byte{1}).Bytes()...)
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"missing count", nil},
+		{"truncated", b[:len(b)-1]},
+		{"trailing bytes", append(append([]byte{}, b...), 0)},
+		{"wrong count", append([]byte{2}, b[1:]...)},
+		{"non-minimal count", append([]byte{0x83, 0x00}, b[1:]...)},
+		{"unsorted", unsorted},
+		{"duplicated", duplicated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SetFromCanonicalBytes(tt.b); err == nil {
+				t.Error("Unexpected success")
+			}
+		})
+	}
+}
+
+func TestSetSampleSeeded(t *testing.T) {
+	set := Set{}
+	if sample := set.SampleSeeded(1, 0); len(sample) != 0 {
+		t.Fatalf("Sample should have been empty but was %v", sample)
+	}
+
+	for i := byte(0); i < 10; i++ {
+		set.Add(NewID([32]byte{i}))
+	}
+
+	expected := set.SampleSeeded(4, 1)
+	if len(expected) != 4 {
+		t.Fatalf("Sample should have had length %d but had %d", 4, len(expected))
+	}
+	for i := 0; i < 10; i++ {
+		// Rebuild the set so that it is iterated over in a different order
+		rebuilt := Set{}
+		rebuilt.Add(set.List()...)
+
+		sample := rebuilt.SampleSeeded(4, 1)
+		for j, id := range sample {
+			if !id.Equals(expected[j]) {
+				t.Fatalf("Sample with the same seed should have been %v but was %v", expected, sample)
+			}
+		}
+	}
+
+	differs := false
+	for seed := uint64(2); seed < 10 && !differs; seed++ {
+		sample := set.SampleSeeded(4, seed)
+		for j, id := range sample {
+			differs = differs || !id.Equals(expected[j])
+		}
+	}
+	if !differs {
+		t.Fatalf("Samples with different seeds should differ")
+	}
+
+	seen := Set{}
+	for _, id := range set.SampleSeeded(20, 2) {
+		if !set.Contains(id) {
+			t.Fatalf("Sample contains %s, which isn't in the set", id)
+		}
+		seen.Add(id)
+	}
+	if !seen.Equals(set) {
+		t.Fatalf("Sample larger than the set should contain every id")
+	}
+}
+
+func TestSetSortedList(t *testing.T) {
+	id0 := NewID([32]byte{0})
+	id1 := NewID([32]byte{1})
+	id2 := NewID([32]byte{2})
+
+	ids := Set{}
+	ids.Add(id2, id0, id1)
+
+	expected := []ID{id0, id1, id2}
+	for i := 0; i < 5; i++ {
+		list := ids.SortedList()
+		if len(list) != len(expected) {
+			t.Fatalf("SortedList returned %d ids, expected %d", len(list), len(expected))
+		}
+		for j, id := range list {
+			if !id.Equals(expected[j]) {
+				t.Fatalf("SortedList[%d] returned %s, expected %s", j, id, expected[j])
+			}
+		}
+	}
+
+	if str := ids.String(); str != "{"+id0.String()+", "+id1.String()+", "+id2.String()+"}" {
+		t.Fatalf("String returned %s, expected sorted ids", str)
+	}
+
+	reordered := Set{}
+	reordered.Add(id1, id2, id0)
+	if !bytes.Equal(ids.Bytes(), reordered.Bytes()) {
+		t.Fatalf("Bytes should be independent of insertion order")
+	} else if !ids.Hash().Equals(reordered.Hash()) {
+		t.Fatalf("Hash should be independent of insertion order")
+	}
+
+	reordered.Remove(id0)
+	if ids.Hash().Equals(reordered.Hash()) {
+		t.Fatalf("Different sets should have different hashes")
+	}
+}
+
+func TestSetToMap(t *testing.T) {
+	id0 := NewID([32]byte{0})
+	id1 := NewID([32]byte{1})
+
+	ids := Set{}
+	ids.Add(id0, id1)
+
+	idMap := ids.ToMap()
+	if len(idMap) != ids.Len() {
+		t.Fatalf("ToMap returned %d ids, expected %d", len(idMap), ids.Len())
+	}
+	for id := range idMap {
+		if !ids.Contains(id) {
+			t.Fatalf("ToMap returned unexpected id %s", id)
+		}
+	}
+
+	ids.Remove(id0)
+	if len(idMap) != 2 {
+		t.Fatalf("Removing from the set shouldn't modify the map")
+	}
+
+	for id := range idMap {
+		delete(idMap, id)
+	}
+	if ids.Len() != 1 || !ids.Contains(id1) {
+		t.Fatalf("Modifying the map shouldn't modify the set")
+	}
+}
+
+func TestSetClear(t *testing.T) {
+	ids := Set{}
+	for i := 0; i < 1000; i++ {
+		ids.Add(Empty.Prefix(uint64(i)))
+	}
+
+	ids.Clear()
+
+	// The map is dropped, rather than emptied, so its capacity is released
+	if ids.Len() != 0 {
+		t.Fatalf("Set has %d ids after being cleared", ids.Len())
+	} else if ids != nil {
+		t.Fatalf("Set should have released its map after being cleared")
+	} else if ids.Contains(Empty.Prefix(0)) {
+		t.Fatalf("Set shouldn't contain a cleared id")
+	}
+
+	ids.Add(Empty)
+	if !ids.Contains(Empty) || ids.Len() != 1 {
+		t.Fatalf("Set should be usable after being cleared")
+	}
+}
+
+func BenchmarkSetClear(b *testing.B) {
+	idList := make([]ID, 100000)
+	for i := range idList {
+		idList[i] = Empty.Prefix(uint64(i))
+	}
+	small := idList[:10]
+
+	b.Run("Clear", func(b *testing.B) {
+		ids := Set{}
+		ids.Add(idList...)
+		ids.Clear()
+
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			ids.Add(small...)
+			ids.List()
+			ids.Clear()
+		}
+	})
+	b.Run("Remove", func(b *testing.B) {
+		ids := Set{}
+		ids.Add(idList...)
+		ids.Remove(idList...)
+
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			ids.Add(small...)
+			ids.List()
+			ids.Remove(small...)
+		}
+	})
+}
+
+func TestSetEqualsDifference(t *testing.T) {
+	id0 := NewID([32]byte{0})
+	id1 := NewID([32]byte{1})
+	id2 := NewID([32]byte{2})
+
+	tests := []struct {
+		name   string
+		s, o   []ID
+		equals bool
+		diff   []ID
+	}{
+		{"both empty", nil, nil, true, nil},
+		{"empty receiver", nil, []ID{id0}, false, nil},
+		{"empty argument", []ID{id0, id1}, nil, false, []ID{id0, id1}},
+		{"disjoint", []ID{id0}, []ID{id1, id2}, false, []ID{id0}},
+		{"overlapping", []ID{id0, id1}, []ID{id1, id2}, false, []ID{id0}},
+		{"subset", []ID{id1}, []ID{id0, id1}, false, nil},
+		{"same", []ID{id0, id1}, []ID{id1, id0}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Set{}
+			s.Add(tt.s...)
+			o := Set{}
+			o.Add(tt.o...)
+
+			if equals := s.Equals(o); equals != tt.equals {
+				t.Fatalf("Equals returned %v, expected %v", equals, tt.equals)
+			} else if equals := o.Equals(s); equals != tt.equals {
+				t.Fatalf("Equals should be symmetric")
+			}
+
+			expected := Set{}
+			expected.Add(tt.diff...)
+			if diff := s.Difference(o); !diff.Equals(expected) {
+				t.Fatalf("Difference returned %s, expected %s", diff, expected)
+			}
+
+			if s.Len() != len(tt.s) || o.Len() != len(tt.o) {
+				t.Fatalf("Difference shouldn't modify either set")
+			}
+		})
+	}
+}
+
+func TestSetCappedList(t *testing.T) {
+	set := Set{}
+	if list := set.CappedList(1); len(list) != 0 {
+		t.Fatalf("List should have been empty but was %v", list)
+	}
+
+	for i := byte(0); i < 5; i++ {
+		set.Add(NewID([32]byte{i}))
+	}
+
+	tests := []struct {
+		name        string
+		size        int
+		expectedLen int
+	}{
+		{"zero size", 0, 0},
+		{"negative size", -1, 0},
+		{"size less than the set", 3, 3},
+		{"size equal to the set", 5, 5},
+		{"size greater than the set", 10, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := set.CappedList(tt.size)
+			if len(list) != tt.expectedLen {
+				t.Fatalf("List should have had length %d but had %d", tt.expectedLen, len(list))
+			} else if cap(list) != tt.expectedLen {
+				t.Fatalf("List should have had capacity %d but had %d", tt.expectedLen, cap(list))
+			}
+
+			seen := Set{}
+			for _, id := range list {
+				if !set.Contains(id) {
+					t.Fatalf("List returned %s, which isn't in the set", id)
+				} else if seen.Contains(id) {
+					t.Fatalf("List returned %s multiple times", id)
+				}
+				seen.Add(id)
+			}
+		})
+	}
+}
+
+func TestSetCappedListSeeded(t *testing.T) {
+	set := Set{}
+	for i := byte(0); i < 10; i++ {
+		set.Add(NewID([32]byte{i}))
+	}
+
+	expected := set.SampleSeeded(4, 1)
+	list := set.CappedListSeeded(4, 1)
+	if len(list) != len(expected) {
+		t.Fatalf("List should have had length %d but had %d", len(expected), len(list))
+	}
+	for i, id := range list {
+		if !id.Equals(expected[i]) {
+			t.Fatalf("List should have matched the sample %v but was %v", expected, list)
+		}
+	}
+}
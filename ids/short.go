@@ -86,6 +86,10 @@ func (id ShortID) Equals(oID ShortID) bool {
 		(id.ID != nil && oID.ID != nil && bytes.Equal(id.Bytes(), oID.Bytes()))
 }
 
+// Compare returns -1, 0, or 1 if the id is lexicographically less than, equal
+// to, or greater than [oID]
+func (id ShortID) Compare(oID ShortID) int { return bytes.Compare(id.Bytes(), oID.Bytes()) }
+
 // Bytes returns the 20 byte hash as a slice. It is assumed this slice is not
 // modified.
 func (id ShortID) Bytes() []byte { return id.ID[:] }
@@ -104,11 +108,7 @@ func (id ShortID) String() string {
 
 type sortShortIDData []ShortID
 
-func (ids sortShortIDData) Less(i, j int) bool {
-	return bytes.Compare(
-		ids[i].Bytes(),
-		ids[j].Bytes()) == -1
-}
+func (ids sortShortIDData) Less(i, j int) bool { return ids[i].Compare(ids[j]) == -1 }
 func (ids sortShortIDData) Len() int      { return len(ids) }
 func (ids sortShortIDData) Swap(i, j int) { ids[j], ids[i] = ids[i], ids[j] }
 
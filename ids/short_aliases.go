@@ -0,0 +1,165 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package ids
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ShortAliaser allows one to give a ShortID aliases and lookup the aliases
+// given to a ShortID. A ShortID can have arbitrarily many aliases; two ShortIDs
+// may not have the same alias. It is safe to use concurrently.
+type ShortAliaser struct {
+	lock    sync.RWMutex
+	dealias map[string]ShortID
+	aliases map[[20]byte][]string
+
+	// onChange, if non-nil, is called with a ShortID whenever its aliases change
+	onChange func(id ShortID)
+}
+
+// Initialize the aliaser to have no aliases
+func (a *ShortAliaser) Initialize() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.dealias = make(map[string]ShortID)
+	a.aliases = make(map[[20]byte][]string)
+}
+
+// Lookup returns the ShortID associated with alias
+func (a *ShortAliaser) Lookup(alias string) (ShortID, error) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	if id, ok := a.dealias[alias]; ok {
+		return id, nil
+	}
+	return ShortID{}, fmt.Errorf("there is no ShortID with alias %s", alias)
+}
+
+// Aliases returns the aliases of a ShortID
+func (a *ShortAliaser) Aliases(id ShortID) []string {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	aliases := a.aliases[id.Key()]
+	if aliases == nil {
+		return nil
+	}
+	return append([]string(nil), aliases...)
+}
+
+// PrimaryAlias returns the first alias of [id]
+func (a *ShortAliaser) PrimaryAlias(id ShortID) (string, error) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	aliases, exists := a.aliases[id.Key()]
+	if !exists || len(aliases) == 0 {
+		return "", fmt.Errorf("there is no alias for ShortID %s", id)
+	}
+	return aliases[0], nil
+}
+
+// Format returns the primary alias of [id] if it has one, and the string form
+// of [id] otherwise. This allows the aliaser to be used as the canonical
+// formatter of ShortIDs.
+func (a *ShortAliaser) Format(id ShortID) string {
+	if alias, err := a.PrimaryAlias(id); err == nil {
+		return alias
+	}
+	return id.String()
+}
+
+// Alias gives [id] the alias [alias]
+func (a *ShortAliaser) Alias(id ShortID, alias string) error {
+	a.lock.Lock()
+	if _, exists := a.dealias[alias]; exists {
+		a.lock.Unlock()
+		return fmt.Errorf("%s is already used as an alias for a ShortID", alias)
+	}
+	key := id.Key()
+
+	a.dealias[alias] = id
+	a.aliases[key] = append(a.aliases[key], alias)
+	a.lock.Unlock()
+
+	a.changed(id)
+	return nil
+}
+
+// SetPrimaryAlias makes [alias], which must already be an alias of [id], the
+// primary alias of [id]
+func (a *ShortAliaser) SetPrimaryAlias(id ShortID, alias string) error {
+	a.lock.Lock()
+	if aliasedID, exists := a.dealias[alias]; !exists || !aliasedID.Equals(id) {
+		a.lock.Unlock()
+		return fmt.Errorf("%s is not an alias of ShortID %s", alias, id)
+	}
+	key := id.Key()
+
+	aliases := a.aliases[key]
+	for i, existing := range aliases {
+		if existing == alias {
+			copy(aliases[1:i+1], aliases[:i])
+			aliases[0] = alias
+			break
+		}
+	}
+	a.lock.Unlock()
+
+	a.changed(id)
+	return nil
+}
+
+// RemoveAlias removes [alias] from the ShortID it was given to
+func (a *ShortAliaser) RemoveAlias(alias string) error {
+	a.lock.Lock()
+	id, exists := a.dealias[alias]
+	if !exists {
+		a.lock.Unlock()
+		return fmt.Errorf("there is no ShortID with alias %s", alias)
+	}
+	key := id.Key()
+
+	delete(a.dealias, alias)
+	aliases := a.aliases[key]
+	for i, existing := range aliases {
+		if existing == alias {
+			aliases = append(aliases[:i], aliases[i+1:]...)
+			break
+		}
+	}
+	if len(aliases) == 0 {
+		delete(a.aliases, key)
+	} else {
+		a.aliases[key] = aliases
+	}
+	a.lock.Unlock()
+
+	a.changed(id)
+	return nil
+}
+
+// OnAliasChange registers [f] to be called with a ShortID whenever the aliases
+// of that ShortID are modified. Passing nil removes the callback. The callback
+// is called without the aliaser being locked, so it may use the aliaser.
+func (a *ShortAliaser) OnAliasChange(f func(id ShortID)) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.onChange = f
+}
+
+func (a *ShortAliaser) changed(id ShortID) {
+	a.lock.RLock()
+	onChange := a.onChange
+	a.lock.RUnlock()
+
+	if onChange != nil {
+		onChange(id)
+	}
+}
@@ -0,0 +1,153 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package ids
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShortAliaserLookupError(t *testing.T) {
+	emptyAliaser := ShortAliaser{}
+	emptyAliaser.Initialize()
+	tests := []struct {
+		label   string
+		aliaser *ShortAliaser
+		alias   string
+		res     ShortID
+	}{
+		{"Unitialized", &ShortAliaser{}, "Batwoman", ShortID{}},
+		{"Empty", &emptyAliaser, "Batman", ShortID{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			res, err := tt.aliaser.Lookup(tt.alias)
+			if !tt.res.Equals(res) {
+				t.Errorf("Got %v, expected %v", res, tt.res)
+			}
+			if err == nil {
+				t.Error("Expected an error due to missing alias")
+			}
+		})
+	}
+}
+
+func TestShortAliaserLookup(t *testing.T) {
+	id := NewShortID([20]byte{'K', 'a', 't', 'e', ' ', 'K', 'a', 'n', 'e'})
+	aliaser := ShortAliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id, "Batwoman")
+
+	res, err := aliaser.Lookup("Batwoman")
+	if err != nil {
+		t.Fatalf("Unexpected error %q", err)
+	}
+	if !id.Equals(res) {
+		t.Fatalf("Got %v, expected %v", res, id)
+	}
+}
+
+func TestShortAliaserAliasesEmpty(t *testing.T) {
+	id := NewShortID([20]byte{'J', 'a', 'm', 'e', 's', ' ', 'G', 'o', 'r', 'd', 'o', 'n'})
+	aliaser := ShortAliaser{}
+	aliaser.Initialize()
+
+	aliases := aliaser.Aliases(id)
+	if len(aliases) != 0 {
+		t.Fatalf("Unexpected aliases %#v", aliases)
+	}
+}
+
+func TestShortAliaserAliases(t *testing.T) {
+	id := NewShortID([20]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	aliaser := ShortAliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id, "Batman")
+	aliaser.Alias(id, "Dark Knight")
+
+	aliases := aliaser.Aliases(id)
+	expected := []string{"Batman", "Dark Knight"}
+	if !reflect.DeepEqual(aliases, expected) {
+		t.Fatalf("Got %v, expected %v", aliases, expected)
+	}
+}
+
+func TestShortAliaserPrimaryAlias(t *testing.T) {
+	id1 := NewShortID([20]byte{'J', 'a', 'm', 'e', 's', ' ', 'G', 'o', 'r', 'd', 'o', 'n'})
+	id2 := NewShortID([20]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	aliaser := ShortAliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id2, "Batman")
+	aliaser.Alias(id2, "Dark Knight")
+
+	res, err := aliaser.PrimaryAlias(id1)
+	if res != "" {
+		t.Fatalf("Unexpected alias for %v", id1)
+	}
+	if err == nil {
+		t.Fatal("Expected an error given an id with no aliases")
+	}
+
+	res, err = aliaser.PrimaryAlias(id2)
+	expected := "Batman"
+	if res != expected {
+		t.Fatalf("Got %v, expected %v", res, expected)
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if err := aliaser.SetPrimaryAlias(id2, "Dark Knight"); err != nil {
+		t.Fatal(err)
+	} else if res, _ := aliaser.PrimaryAlias(id2); res != "Dark Knight" {
+		t.Fatalf("Got %v, expected %v", res, "Dark Knight")
+	} else if err := aliaser.SetPrimaryAlias(id1, "Batman"); err == nil {
+		t.Fatal("Expected an error given an alias of a different id")
+	}
+}
+
+func TestShortAliaserAliasClash(t *testing.T) {
+	id1 := NewShortID([20]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	id2 := NewShortID([20]byte{'D', 'i', 'c', 'k', ' ', 'G', 'r', 'a', 'y', 's', 'o', 'n'})
+	aliaser := ShortAliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id1, "Batman")
+
+	err := aliaser.Alias(id2, "Batman")
+	if err == nil {
+		t.Fatalf("Expected an error, due to an existing alias")
+	}
+}
+
+func TestShortAliaserRemoveAlias(t *testing.T) {
+	id := NewShortID([20]byte{'S', 'e', 'l', 'i', 'n', 'a', ' ', 'K', 'y', 'l', 'e'})
+	aliaser := ShortAliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id, "Catwoman")
+
+	if err := aliaser.RemoveAlias("Catwoman"); err != nil {
+		t.Fatal(err)
+	} else if _, err := aliaser.Lookup("Catwoman"); err == nil {
+		t.Fatal("Expected an error due to a removed alias")
+	} else if aliases := aliaser.Aliases(id); len(aliases) != 0 {
+		t.Fatalf("Unexpected aliases %#v", aliases)
+	} else if err := aliaser.RemoveAlias("Catwoman"); err == nil {
+		t.Fatal("Expected an error due to a missing alias")
+	}
+}
+
+func TestShortAliaserFormat(t *testing.T) {
+	id1 := NewShortID([20]byte{'J', 'a', 'm', 'e', 's', ' ', 'G', 'o', 'r', 'd', 'o', 'n'})
+	id2 := NewShortID([20]byte{'B', 'r', 'u', 'c', 'e', ' ', 'W', 'a', 'y', 'n', 'e'})
+	aliaser := ShortAliaser{}
+	aliaser.Initialize()
+	aliaser.Alias(id2, "Batman")
+
+	if formatted := aliaser.Format(id1); formatted != id1.String() {
+		t.Fatalf("Got %v, expected %v", formatted, id1.String())
+	}
+	if formatted := aliaser.Format(id2); formatted != "Batman" {
+		t.Fatalf("Got %v, expected %v", formatted, "Batman")
+	}
+}
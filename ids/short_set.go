@@ -63,6 +63,26 @@ func (ids ShortSet) CappedList(size int) []ShortID {
 	return idList
 }
 
+// CappedListSeeded returns a list of length at most [size] in the same manner
+// as CappedList. However, rather than depending on the iteration order of the
+// set, the ids are sampled using [seed], so the same seed and set always
+// produce the same list.
+func (ids ShortSet) CappedListSeeded(size int, seed uint64) []ShortID {
+	idList := ids.List()
+	SortShortIDs(idList)
+	size = seededSample(len(idList), size, seed, func(i, j int) {
+		idList[i], idList[j] = idList[j], idList[i]
+	})
+	return idList[:size]
+}
+
+// SampleSeeded returns [n] ids sampled from this set in the same manner as
+// CappedListSeeded, or every id if the set has fewer than [n] ids. It mirrors
+// Set.SampleSeeded.
+func (ids ShortSet) SampleSeeded(n int, seed uint64) []ShortID {
+	return ids.CappedListSeeded(n, seed)
+}
+
 // List converts this set into a list
 func (ids ShortSet) List() []ShortID {
 	idList := make([]ShortID, len(ids))[:0]
@@ -211,6 +211,45 @@ func TestShortSetCappedList(t *testing.T) {
 	}
 }
 
+func TestShortSetCappedListSeeded(t *testing.T) {
+	set := ShortSet{}
+	if list := set.CappedListSeeded(1, 0); len(list) != 0 {
+		t.Fatalf("List should have been empty but was %v", list)
+	}
+
+	for i := byte(0); i < 10; i++ {
+		set.Add(NewShortID([20]byte{i}))
+	}
+
+	expected := set.CappedListSeeded(4, 1)
+	if len(expected) != 4 {
+		t.Fatalf("List should have had length %d but had %d", 4, len(expected))
+	}
+	for i := 0; i < 10; i++ {
+		// Rebuild the set so that it is iterated over in a different order
+		rebuilt := ShortSet{}
+		rebuilt.Add(set.List()...)
+
+		list := rebuilt.CappedListSeeded(4, 1)
+		for j, id := range list {
+			if !id.Equals(expected[j]) {
+				t.Fatalf("List with the same seed should have been %v but was %v", expected, list)
+			}
+		}
+	}
+
+	seen := ShortSet{}
+	for _, id := range set.CappedListSeeded(20, 2) {
+		if !set.Contains(id) {
+			t.Fatalf("List contains %s, which isn't in the set", id)
+		}
+		seen.Add(id)
+	}
+	if !seen.Equals(set) {
+		t.Fatalf("List capped above the set size should contain every id")
+	}
+}
+
 func TestShortSetString(t *testing.T) {
 	set := ShortSet{}
 
@@ -237,3 +276,21 @@ func TestShortSetString(t *testing.T) {
 		t.Fatalf("Should only have one %s in %s", ",", str)
 	}
 }
+
+func TestShortSetSampleSeeded(t *testing.T) {
+	set := ShortSet{}
+	for i := byte(0); i < 10; i++ {
+		set.Add(NewShortID([20]byte{i}))
+	}
+
+	expected := set.CappedListSeeded(4, 1)
+	sample := set.SampleSeeded(4, 1)
+	if len(sample) != len(expected) {
+		t.Fatalf("Sample should have had length %d but had %d", len(expected), len(sample))
+	}
+	for i, id := range sample {
+		if !id.Equals(expected[i]) {
+			t.Fatalf("Sample should have matched the list %v but was %v", expected, sample)
+		}
+	}
+}
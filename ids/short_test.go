@@ -0,0 +1,60 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package ids
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShortIDEquals(t *testing.T) {
+	id0 := NewShortID([20]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 's'})
+	id1 := NewShortID([20]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 's'})
+	id2 := NewShortID([20]byte{'e', 'v', 'a', ' ', 'l', 'a', 'b', 's'})
+
+	if !id0.Equals(id1) {
+		t.Fatalf("%s should equal %s", id0, id1)
+	} else if id0.Equals(id2) {
+		t.Fatalf("%s shouldn't equal %s", id0, id2)
+	} else if id0.Equals(ShortID{}) {
+		t.Fatalf("%s shouldn't equal the zero id", id0)
+	} else if !(ShortID{}).Equals(ShortID{}) {
+		t.Fatalf("The zero id should equal itself")
+	}
+}
+
+func TestShortIDCompare(t *testing.T) {
+	id0 := NewShortID([20]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 's'})
+	id1 := NewShortID([20]byte{'e', 'v', 'a', ' ', 'l', 'a', 'b', 's'})
+
+	if cmp := id0.Compare(id1); cmp != -1 {
+		t.Fatalf("Compare should have returned -1, returned %d", cmp)
+	} else if cmp := id1.Compare(id0); cmp != 1 {
+		t.Fatalf("Compare should have returned 1, returned %d", cmp)
+	} else if cmp := id0.Compare(NewShortID(id0.Key())); cmp != 0 {
+		t.Fatalf("Compare should have returned 0, returned %d", cmp)
+	}
+}
+
+func TestSortShortIDs(t *testing.T) {
+	ids := []ShortID{
+		NewShortID([20]byte{'e', 'v', 'a', ' ', 'l', 'a', 'b', 's'}),
+		NewShortID([20]byte{'W', 'a', 'l', 'l', 'e', ' ', 'l', 'a', 'b', 's'}),
+		NewShortID([20]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 's'}),
+		NewShortID([20]byte{'b', 'v', 'a', ' ', 'l', 'a', 'b', 's'}),
+	}
+	SortShortIDs(ids)
+	expected := []ShortID{
+		NewShortID([20]byte{'W', 'a', 'l', 'l', 'e', ' ', 'l', 'a', 'b', 's'}),
+		NewShortID([20]byte{'a', 'v', 'a', ' ', 'l', 'a', 'b', 's'}),
+		NewShortID([20]byte{'b', 'v', 'a', ' ', 'l', 'a', 'b', 's'}),
+		NewShortID([20]byte{'e', 'v', 'a', ' ', 'l', 'a', 'b', 's'}),
+	}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Fatal("[]ShortID was not sorted lexographically")
+	}
+	if !IsSortedAndUniqueShortIDs(ids) {
+		t.Fatal("Sorted []ShortID should be reported as sorted and unique")
+	}
+}
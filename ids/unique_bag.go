@@ -81,6 +81,35 @@ func (b *UniqueBag) Bag(alpha int) Bag {
 	return bag
 }
 
+// Clone returns a copy of this bag that can be modified without modifying
+// this bag
+func (b UniqueBag) Clone() UniqueBag {
+	if b == nil {
+		return nil
+	}
+	clone := make(UniqueBag, len(b))
+	for id, set := range b {
+		clone[id] = set
+	}
+	return clone
+}
+
+// Equals returns true if every ID has the same set in both bags. An ID with an
+// empty set is considered equal to an ID that isn't in the bag.
+func (b UniqueBag) Equals(other UniqueBag) bool {
+	for id, set := range b {
+		if other[id] != set {
+			return false
+		}
+	}
+	for id, set := range other {
+		if b[id] != set {
+			return false
+		}
+	}
+	return true
+}
+
 func (b *UniqueBag) String() string {
 	sb := strings.Builder{}
 
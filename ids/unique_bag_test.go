@@ -104,3 +104,79 @@ func TestUniqueBag(t *testing.T) {
 		t.Fatalf("Set of Unique Bag missing element")
 	}
 }
+
+func TestUniqueBagEquals(t *testing.T) {
+	id1 := Empty.Prefix(1)
+	id2 := Empty.Prefix(2)
+
+	ub1 := UniqueBag{}
+	ub1.Add(1, id1, id2)
+	ub1.Add(2, id1)
+
+	ub2 := UniqueBag{}
+	ub2.Add(2, id1)
+	ub2.Add(1, id2, id1)
+
+	if !ub1.Equals(ub2) || !ub2.Equals(ub1) {
+		t.Fatalf("Bags with the same sets should be equal")
+	}
+
+	ub2.Add(3, id2)
+	if ub1.Equals(ub2) || ub2.Equals(ub1) {
+		t.Fatalf("Bags differing by a single bit shouldn't be equal")
+	}
+
+	bs := BitSet(0)
+	bs.Add(3)
+	ub2.DifferenceSet(id2, bs)
+	if !ub1.Equals(ub2) {
+		t.Fatalf("Bags should be equal after removing the differing bit")
+	}
+
+	ub1.DifferenceSet(Empty.Prefix(3), bs)
+	if !ub1.Equals(ub2) || !ub2.Equals(ub1) {
+		t.Fatalf("An empty set should be equal to a missing set")
+	}
+
+	if !(UniqueBag{}).Equals(nil) {
+		t.Fatalf("Empty bags should be equal")
+	}
+}
+
+func TestUniqueBagClone(t *testing.T) {
+	id1 := Empty.Prefix(1)
+	id2 := Empty.Prefix(2)
+
+	if clone := UniqueBag(nil).Clone(); clone != nil {
+		t.Fatalf("Clone of a nil bag should be nil")
+	}
+
+	ub := UniqueBag{}
+	ub.Add(1, id1, id2)
+	ub.Add(2, id1)
+
+	clone := ub.Clone()
+	if !clone.Equals(ub) {
+		t.Fatalf("Clone should equal the original bag")
+	}
+
+	bs := BitSet(0)
+	bs.Add(1)
+	ub.DifferenceSet(id1, bs)
+	ub.Add(3, id2, Empty.Prefix(3))
+
+	if set := clone.GetSet(id1); set.Len() != 2 || !set.Contains(1) || !set.Contains(2) {
+		t.Fatalf("Modifying the original bag modified the clone's set %s", set)
+	} else if set := clone.GetSet(id2); set.Len() != 1 || !set.Contains(1) {
+		t.Fatalf("Modifying the original bag modified the clone's set %s", set)
+	} else if len(clone) != 2 {
+		t.Fatalf("Modifying the original bag added to the clone")
+	} else if clone.Equals(ub) {
+		t.Fatalf("Clone shouldn't equal the modified original bag")
+	}
+
+	clone.Add(4, id1)
+	if ub.GetSet(id1).Contains(4) {
+		t.Fatalf("Modifying the clone modified the original bag")
+	}
+}
@@ -0,0 +1,126 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package avalanchetest provides helpers for testing and benchmarking
+// implementations of avalanche consensus.
+package avalanchetest
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/avalanche"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+// DefaultWidth is the number of vertices in each layer of the DAG built by
+// BenchmarkConsensus
+const DefaultWidth = 8
+
+var offset = uint64(0)
+
+func generateID() ids.ID {
+	offset++
+	return ids.Empty.Prefix(offset)
+}
+
+// BenchmarkConsensus benchmarks the instances created by [factory] finalizing
+// a DAG of [dagSize] vertices, built in layers of DefaultWidth vertices.
+func BenchmarkConsensus(b *testing.B, factory avalanche.Factory, dagSize int) {
+	depth := (dagSize + DefaultWidth - 1) / DefaultWidth
+	BenchmarkConsensusShape(b, factory, DefaultWidth, depth)
+}
+
+// BenchmarkConsensusShape benchmarks the instances created by [factory]
+// finalizing a DAG of [depth] layers of [width] vertices. Every vertex issues
+// one virtuous transaction and has up to two parents in the previous layer.
+// Each poll unanimously votes for the last layer, so every iteration finishes
+// after BetaVirtuous polls.
+func BenchmarkConsensusShape(b *testing.B, factory avalanche.Factory, width, depth int) {
+	if width <= 0 || depth <= 0 {
+		b.Fatalf("DAG shape %dx%d must be positive", width, depth)
+	}
+
+	ctx := snow.DefaultContextTest()
+	params := avalanche.Parameters{
+		Parameters: snowball.Parameters{
+			K:                 20,
+			Alpha:             15,
+			BetaVirtuous:      20,
+			BetaRogue:         30,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		genesis, layers := newLayeredDAG(width, depth)
+		votes := ids.UniqueBag{}
+		for _, vtx := range layers[depth-1] {
+			for i := 0; i < params.K; i++ {
+				votes.Add(uint(i), vtx.ID())
+			}
+		}
+		params.Metrics = prometheus.NewRegistry()
+		b.StartTimer()
+
+		cons := factory.New()
+		if err := cons.Initialize(ctx, params, genesis); err != nil {
+			b.Fatal(err)
+		}
+		for _, layer := range layers {
+			for _, vtx := range layer {
+				cons.Add(vtx)
+			}
+		}
+
+		for poll := 0; !cons.Finalized(); poll++ {
+			if poll > params.BetaRogue {
+				b.Fatalf("DAG wasn't finalized after %d polls", poll)
+			}
+			cons.RecordPoll(votes)
+		}
+	}
+}
+
+// newLayeredDAG returns two accepted genesis vertices and [depth] layers of
+// [width] processing vertices built on top of them
+func newLayeredDAG(width, depth int) ([]avalanche.Vertex, [][]avalanche.Vertex) {
+	genesis := []avalanche.Vertex{
+		&TestVertex{Identifier: generateID(), Stat: choices.Accepted},
+		&TestVertex{Identifier: generateID(), Stat: choices.Accepted},
+	}
+
+	layers := make([][]avalanche.Vertex, depth)
+	parents := genesis
+	for d := range layers {
+		layer := make([]avalanche.Vertex, width)
+		for i := range layer {
+			tx := &snowstorm.TestTx{Identifier: generateID()}
+			tx.Ins.Add(generateID())
+
+			deps := []avalanche.Vertex{parents[i%len(parents)]}
+			if other := parents[(i+1)%len(parents)]; !other.ID().Equals(deps[0].ID()) {
+				deps = append(deps, other)
+			}
+			layer[i] = &TestVertex{
+				Identifier:   generateID(),
+				Deps:         deps,
+				Transactions: []snowstorm.Tx{tx},
+				Stat:         choices.Processing,
+			}
+		}
+		layers[d] = layer
+		parents = layer
+	}
+	return genesis, layers
+}
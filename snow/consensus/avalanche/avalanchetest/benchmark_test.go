@@ -0,0 +1,32 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanchetest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ava-labs/gecko/snow/consensus/avalanche"
+)
+
+func BenchmarkTopological(b *testing.B) {
+	for _, dagSize := range []int{16, 128, 1024} {
+		b.Run(fmt.Sprintf("size=%d", dagSize), func(b *testing.B) {
+			BenchmarkConsensus(b, avalanche.TopologicalFactory{}, dagSize)
+		})
+	}
+}
+
+func BenchmarkTopologicalShape(b *testing.B) {
+	shapes := []struct{ width, depth int }{
+		{width: 1, depth: 64},
+		{width: 8, depth: 8},
+		{width: 64, depth: 1},
+	}
+	for _, shape := range shapes {
+		b.Run(fmt.Sprintf("width=%d/depth=%d", shape.width, shape.depth), func(b *testing.B) {
+			BenchmarkConsensusShape(b, avalanche.TopologicalFactory{}, shape.width, shape.depth)
+		})
+	}
+}
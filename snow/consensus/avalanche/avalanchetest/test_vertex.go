@@ -0,0 +1,53 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanchetest
+
+import (
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/avalanche"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+// TestVertex is a useful test vertex
+type TestVertex struct {
+	Identifier   ids.ID
+	Deps         []avalanche.Vertex
+	Transactions []snowstorm.Tx
+	Stat         choices.Status
+	Bits         []byte
+}
+
+// ID implements the avalanche.Vertex interface
+func (v *TestVertex) ID() ids.ID { return v.Identifier }
+
+// ParentIDs implements the avalanche.Vertex interface
+func (v *TestVertex) ParentIDs() []ids.ID {
+	parentIDs := make([]ids.ID, len(v.Deps))
+	for i, parent := range v.Deps {
+		parentIDs[i] = parent.ID()
+	}
+	return parentIDs
+}
+
+// Parents implements the avalanche.Vertex interface
+func (v *TestVertex) Parents() []avalanche.Vertex { return v.Deps }
+
+// Txs implements the avalanche.Vertex interface
+func (v *TestVertex) Txs() []snowstorm.Tx { return v.Transactions }
+
+// Status implements the avalanche.Vertex interface
+func (v *TestVertex) Status() choices.Status { return v.Stat }
+
+// Live implements the avalanche.Vertex interface
+func (v *TestVertex) Live() {}
+
+// Accept implements the avalanche.Vertex interface
+func (v *TestVertex) Accept() { v.Stat = choices.Accepted }
+
+// Reject implements the avalanche.Vertex interface
+func (v *TestVertex) Reject() { v.Stat = choices.Rejected }
+
+// Bytes returns the bits
+func (v *TestVertex) Bytes() []byte { return v.Bits }
@@ -0,0 +1,63 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"encoding/binary"
+
+	"github.com/ava-labs/gecko/ids"
+)
+
+// bloomHashes is the number of bits set in a bloomFilter per added ID
+const bloomHashes = 4
+
+// bloomFilter is a fixed size Bloom filter of IDs. Because IDs are already
+// hashes, the bit indices are derived directly from the bytes of the ID.
+type bloomFilter struct {
+	bits []uint64
+}
+
+// initialize the filter to be empty with [size] bits. If [size] is zero, the
+// filter is disabled and contains nothing.
+func (f *bloomFilter) initialize(size int) {
+	f.bits = make([]uint64, (size+63)/64)
+}
+
+// indices returns the bit indices that represent [id] in the filter
+func (f *bloomFilter) indices(id ids.ID) [bloomHashes]uint64 {
+	bytes := id.Key()
+	h1 := binary.BigEndian.Uint64(bytes[:8])
+	h2 := binary.BigEndian.Uint64(bytes[8:16])
+
+	numBits := uint64(len(f.bits)) * 64
+	indices := [bloomHashes]uint64{}
+	for i := range indices {
+		indices[i] = (h1 + uint64(i)*h2) % numBits
+	}
+	return indices
+}
+
+// add [id] to the filter
+func (f *bloomFilter) add(id ids.ID) {
+	if len(f.bits) == 0 {
+		return
+	}
+	for _, index := range f.indices(id) {
+		f.bits[index/64] |= 1 << (index % 64)
+	}
+}
+
+// contains returns false if [id] was never added to the filter. It may return
+// true for IDs that were never added.
+func (f *bloomFilter) contains(id ids.ID) bool {
+	if len(f.bits) == 0 {
+		return false
+	}
+	for _, index := range f.indices(id) {
+		if f.bits[index/64]&(1<<(index%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/choices"
+)
+
+// pendingVotes are votes that still need to be pushed to a vertex and its
+// ancestors
+type pendingVotes struct {
+	vtx   Vertex
+	votes ids.BitSet
+}
+
+// conflictless returns true if every transaction in the live set is virtuous
+// and the live set is too small for any vertex to exceed maxInDegree, so the
+// conflictless fast path produces the same result as the topological sort
+func (ta *Topological) conflictless() bool {
+	return len(ta.nodes) <= maxInDegree && ta.rogueLiveTxs.Len() == 0
+}
+
+// updateRogueLiveTxs records the txs that stopped being virtuous when [vtx]
+// was added. Those are its own txs that conflict with another tx, or were
+// already rejected, along with the live txs they conflict with. A tx never
+// becomes virtuous again, so it stays rogue until it is accepted or leaves the
+// live set.
+func (ta *Topological) updateRogueLiveTxs(vtx Vertex) {
+	virtuousTxs := ta.cg.Virtuous()
+	for _, tx := range vtx.Txs() {
+		switch tx.Status() {
+		case choices.Accepted:
+		case choices.Rejected:
+			ta.rogueLiveTxs.Add(tx.ID())
+		default:
+			if txID := tx.ID(); !virtuousTxs.Contains(txID) {
+				ta.rogueLiveTxs.Add(txID)
+			}
+			for _, conflictID := range ta.cg.Conflicts(tx).List() {
+				if _, live := ta.txVertices[conflictID.Key()]; live {
+					ta.rogueLiveTxs.Add(conflictID)
+				}
+			}
+		}
+	}
+}
+
+// tracedConflictlessVotes collects the votes for each transaction with
+// conflictlessVotes inside of a child span of [pollSpan]
+func (ta *Topological) tracedConflictlessVotes(pollSpan Span, responses ids.UniqueBag) (*ids.Bag, error) {
+	span := ta.startSpan(pollSpan, "conflictlessVotes")
+	defer endSpan(span)
+
+	votes, numVertices, err := ta.conflictlessVotes(responses, ta.alpha(responses))
+	setSpanAttribute(span, "vertices", numVertices)
+	if err != nil {
+		return nil, err
+	}
+	setSpanAttribute(span, "votes", votes.Len())
+	return votes, nil
+}
+
+// conflictlessVotes collects the votes for each transaction in the same manner
+// as calculateInDegree and pushVotes, but without topologically sorting the
+// live set first. Instead, the voters of each voted for vertex are pushed
+// directly to its ancestors, stopping at any ancestor that already has those
+// voters. A vertex is revisited once for every new set of voters that reaches
+// it, so this is only worthwhile when there are no conflicts, as then the
+// validators are expected to vote for the same frontier and each vertex is
+// usually reached by all of its voters at once. The number of vertices that
+// received votes is returned along with the votes. The returned bag is pooled,
+// so it must be returned with ids.PutBag once it is no longer needed.
+func (ta *Topological) conflictlessVotes(
+	responses ids.UniqueBag,
+	alpha int) (*ids.Bag, int, error) {
+	vtxVotes := make(map[[32]byte]ids.BitSet, len(responses))
+	wastedVotes := 0
+	lateVotes := 0
+
+	pending := []pendingVotes(nil)
+	for _, vote := range responses.List() {
+		vtx := ta.nodes[vote.Key()]
+		if vtx == nil {
+			numVotes := responses.GetSet(vote).Len()
+			wastedVotes += numVotes
+			if ta.decisions.contains(vote) {
+				lateVotes += numVotes
+			}
+			continue
+		}
+
+		// Every voter must have been one of the K sampled validators
+		voters := ids.BitSet(0)
+		if err := voters.UnionChecked(responses.GetSet(vote), uint(ta.params.K)); err != nil {
+			return nil, len(vtxVotes), err
+		}
+
+		pending = append(pending, pendingVotes{
+			vtx:   vtx,
+			votes: voters,
+		})
+		for len(pending) > 0 {
+			newLen := len(pending) - 1
+			current := pending[newLen]
+			pending = pending[:newLen]
+
+			key := current.vtx.ID().Key()
+			previousVotes, visited := vtxVotes[key]
+			newVotes := current.votes
+			newVotes.Difference(previousVotes)
+			if visited && newVotes.Len() == 0 {
+				continue // My ancestors have already been given these votes
+			}
+			previousVotes.Union(newVotes)
+			vtxVotes[key] = previousVotes
+
+			for _, dep := range ta.parents(current.vtx) {
+				// The vertex may have been decided, no need to vote in that case
+				if !dep.Status().Decided() {
+					pending = append(pending, pendingVotes{
+						vtx:   dep,
+						votes: newVotes,
+					})
+				}
+			}
+		}
+	}
+
+	votes := make(ids.UniqueBag, len(vtxVotes))
+	for key, voters := range vtxVotes {
+		if vtx := ta.nodes[key]; vtx != nil {
+			for _, tx := range vtx.Txs() {
+				votes.UnionSet(tx.ID(), voters)
+			}
+		}
+	}
+
+	ta.metrics.WastedVotes(wastedVotes)
+	ta.metrics.LateVotes(lateVotes)
+	return ta.voteBag(votes, alpha), len(vtxVotes), nil
+}
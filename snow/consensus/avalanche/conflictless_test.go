@@ -0,0 +1,258 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+// newConflictlessDAG returns an instance whose live set is [depth] layers of
+// [width] vertices, where each vertex has two parents in the previous layer
+// and issues a single virtuous transaction
+func newConflictlessDAG(params Parameters, width, depth int) (*Topological, [][]*Vtx) {
+	genesis := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := &Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, genesis)
+
+	layers := make([][]*Vtx, depth)
+	parents := genesis
+	for i := range layers {
+		layer := make([]*Vtx, width)
+		for j := range layer {
+			tx := &snowstorm.TestTx{
+				Identifier: GenerateID(),
+				Stat:       choices.Processing,
+			}
+			tx.Ins.Add(GenerateID())
+
+			layer[j] = &Vtx{
+				dependencies: []Vertex{parents[j%len(parents)], parents[(j+1)%len(parents)]},
+				id:           GenerateID(),
+				txs:          []snowstorm.Tx{tx},
+				height:       i + 1,
+				status:       choices.Processing,
+			}
+			ta.Add(layer[j])
+		}
+
+		parents = make([]Vertex, width)
+		for j, vtx := range layer {
+			parents[j] = vtx
+		}
+		layers[i] = layer
+	}
+	return ta, layers
+}
+
+func TestConflictless(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      3,
+			BetaRogue:         5,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	ta, layers := newConflictlessDAG(params, 2, 2)
+
+	if !ta.conflictless() {
+		t.Fatalf("Live set without conflicts should be conflictless")
+	}
+
+	tx := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx.Ins.Union(layers[1][0].txs[0].InputIDs())
+
+	ta.Add(&Vtx{
+		dependencies: []Vertex{layers[0][0]},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx},
+		height:       2,
+		status:       choices.Processing,
+	})
+
+	if ta.conflictless() {
+		t.Fatalf("Live set with a conflict shouldn't be conflictless")
+	}
+
+	// Once the conflict is resolved, the live set is conflictless again
+	for i := 0; i < params.BetaRogue; i++ {
+		votes := ids.UniqueBag{}
+		votes.Add(0, layers[1][0].id)
+		ta.RecordPoll(votes)
+	}
+
+	if layers[1][0].Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if !ta.conflictless() {
+		t.Fatalf("Live set should be conflictless once the conflict is resolved")
+	}
+}
+
+func TestConflictlessVotesMatchesTopologicalSort(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 5,
+			Alpha:             3,
+			BetaVirtuous:      100,
+			BetaRogue:         100,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	ta, layers := newConflictlessDAG(params, 4, 5)
+
+	votes := ids.UniqueBag{}
+	for i := uint(0); i < 5; i++ {
+		layer := layers[int(i)%len(layers)]
+		votes.Add(i, layer[int(i)%len(layer)].id)
+	}
+	votes.Add(0, layers[len(layers)-1][0].id, layers[len(layers)-1][3].id)
+	votes.Add(4, GenerateID())
+
+	expected, err := ta.tracedKahnVotes(nil, votes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ids.PutBag(expected)
+
+	result, numVertices, err := ta.conflictlessVotes(votes, ta.alpha(votes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ids.PutBag(result)
+
+	if !result.Equals(*expected) {
+		t.Fatalf("Expected votes %s, got %s", expected, result)
+	}
+	if kahns, _, err := ta.calculateInDegree(votes); err != nil {
+		t.Fatal(err)
+	} else if numVertices != len(kahns) {
+		t.Fatalf("Expected %d vertices to receive votes, got %d", len(kahns), numVertices)
+	}
+
+	// A voter that wasn't sampled invalidates the poll on both paths
+	votes.Add(uint(params.K), layers[0][0].id)
+	if _, err := ta.tracedKahnVotes(nil, votes); err == nil {
+		t.Fatalf("Should have errored on an unsampled voter")
+	}
+	if _, _, err := ta.conflictlessVotes(votes, ta.alpha(votes)); err == nil {
+		t.Fatalf("Should have errored on an unsampled voter")
+	}
+}
+
+func TestConflictlessRecordPoll(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	ta, layers := newConflictlessDAG(params, 2, 3)
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, layers[2][0].id)
+	votes.Add(1, layers[1][1].id)
+	ta.RecordPoll(votes)
+
+	for i, layer := range layers {
+		for j, vtx := range layer {
+			// Both validators voted for the first layer and for the second
+			// vertex of the second layer
+			expected := choices.Processing
+			if i == 0 || (i == 1 && j == 1) {
+				expected = choices.Accepted
+			}
+			if status := vtx.Status(); status != expected {
+				t.Fatalf("Vertex %d of layer %d should be %s, got %s", j, i, expected, status)
+			}
+		}
+	}
+}
+
+func benchmarkVotes(b *testing.B, collect func(*Topological, ids.UniqueBag) (*ids.Bag, error)) {
+	const (
+		width = 10
+		depth = 100
+		k     = 20
+	)
+
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 k,
+			Alpha:             k/2 + 1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	ta, layers := newConflictlessDAG(params, width, depth)
+
+	// Every validator votes for the frontier
+	votes := ids.UniqueBag{}
+	for i := uint(0); i < k; i++ {
+		for _, vtx := range layers[depth-1] {
+			votes.Add(i, vtx.id)
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		bag, err := collect(ta, votes)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ids.PutBag(bag)
+	}
+}
+
+// BenchmarkConflictlessVotes benchmarks collecting the votes of an all
+// virtuous DAG on the conflictless fast path
+func BenchmarkConflictlessVotes(b *testing.B) {
+	benchmarkVotes(b, func(ta *Topological, votes ids.UniqueBag) (*ids.Bag, error) {
+		bag, _, err := ta.conflictlessVotes(votes, ta.alpha(votes))
+		return bag, err
+	})
+}
+
+// BenchmarkKahnVotes benchmarks collecting the votes of an all virtuous DAG
+// with a topological sort
+func BenchmarkKahnVotes(b *testing.B) {
+	benchmarkVotes(b, func(ta *Topological, votes ids.UniqueBag) (*ids.Bag, error) {
+		return ta.tracedKahnVotes(nil, votes)
+	})
+}
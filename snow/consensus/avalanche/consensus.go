@@ -23,8 +23,10 @@ type Consensus interface {
 	// the mutation statuses, and the consumer statuses. If accept or reject is
 	// called, the status maps should be immediately updated accordingly.
 	// Assumes each element in the accepted frontier will return accepted from
-	// the join status map.
-	Initialize(*snow.Context, Parameters, []Vertex)
+	// the join status map. Returns an error if the parameters are invalid or
+	// the metrics couldn't be registered. The instance is still initialized
+	// when an error is returned, but it should not be relied upon.
+	Initialize(*snow.Context, Parameters, []Vertex) error
 
 	// Returns the parameters that describe this avalanche instance
 	Parameters() Parameters
@@ -57,6 +59,11 @@ type Consensus interface {
 	// been added, the result is dropped.
 	RecordPoll(ids.UniqueBag)
 
+	// RecordPollFrom collects the results of a network poll in the same manner
+	// as RecordPoll. The i-th validator in the provided list is the validator
+	// whose response was recorded with index i in the results.
+	RecordPollFrom(ids.UniqueBag, []ids.ShortID)
+
 	// Quiesce returns true iff all vertices that have been added but not been accepted or rejected are rogue.
 	// Note, it is possible that after returning quiesce, a new decision may be added such
 	// that this instance should no longer quiesce.
@@ -66,6 +73,11 @@ type Consensus interface {
 	// finalized. Note, it is possible that after returning finalized, a new
 	// decision may be added such that this instance is no longer finalized.
 	Finalized() bool
+
+	// PendingWork returns an estimate of the work remaining to finalize the
+	// added transactions, as the number of processing transactions that
+	// haven't reached their beta confidence.
+	PendingWork() int
 }
 
 // Vertex is a collection of multiple transactions tied to other vertices
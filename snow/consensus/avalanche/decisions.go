@@ -0,0 +1,188 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/utils/timer"
+)
+
+// The number of removed decisions that may remain in the order of decisions
+// before it is compacted, in addition to the number of remembered decisions
+const minDecisionsOrderSize = 16
+
+type decision struct {
+	vtxID ids.ID
+	time  time.Time
+}
+
+// decisions remembers the statuses of recently decided vertices. It is safe to
+// access concurrently, so that it can be pruned in the background while the
+// consensus instance is being polled.
+type decisions struct {
+	lock sync.Mutex
+
+	// Used to timestamp decisions. This is the clock of the instance, so it
+	// must not be faked while a pruner is running.
+	clock *timer.Clock
+	// Maps vtxID -> the status the vertex was decided with
+	statuses map[[32]byte]choices.Status
+	// Decided vertices, sorted by the time they were decided
+	order []decision
+}
+
+func (d *decisions) initialize(clock *timer.Clock) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.clock = clock
+	d.statuses = make(map[[32]byte]choices.Status)
+	d.order = nil
+}
+
+// add records that the vertex [vtxID] was decided with [status]
+func (d *decisions) add(vtxID ids.ID, status choices.Status) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.statuses[vtxID.Key()] = status
+	d.order = append(d.order, decision{
+		vtxID: vtxID,
+		time:  d.clock.Time(),
+	})
+}
+
+// contains returns true if the decision of the vertex [vtxID] is remembered
+func (d *decisions) contains(vtxID ids.ID) bool {
+	_, ok := d.status(vtxID)
+	return ok
+}
+
+// status returns the status the vertex [vtxID] was decided with, if the
+// decision is remembered
+func (d *decisions) status(vtxID ids.ID) (choices.Status, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	status, ok := d.statuses[vtxID.Key()]
+	return status, ok
+}
+
+// prune removes the decisions made more than [retention] ago and returns the
+// number of decisions that were removed
+func (d *decisions) prune(retention time.Duration) int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	cutoff := d.clock.Time().Add(-retention)
+	numPruned := 0
+	i := 0
+	for ; i < len(d.order) && d.order[i].time.Before(cutoff); i++ {
+		key := d.order[i].vtxID.Key()
+		if _, ok := d.statuses[key]; ok {
+			delete(d.statuses, key)
+			numPruned++
+		}
+	}
+
+	// Copy the remaining decisions so the pruned ones can be garbage collected
+	d.order = append([]decision(nil), d.order[i:]...)
+	return numPruned
+}
+
+// remove forgets the decision of the vertex [vtxID], if it is remembered
+func (d *decisions) remove(vtxID ids.ID) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.statuses, vtxID.Key())
+
+	// Removed decisions are only dropped from the order once most of the order
+	// has been removed, so that removing a decision is amortized O(1)
+	if len(d.order) <= 2*len(d.statuses)+minDecisionsOrderSize {
+		return
+	}
+	order := make([]decision, 0, 2*len(d.statuses))
+	for _, decision := range d.order {
+		if _, ok := d.statuses[decision.vtxID.Key()]; ok {
+			order = append(order, decision)
+		}
+	}
+	d.order = order
+}
+
+// len returns the number of decisions currently remembered
+func (d *decisions) len() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return len(d.statuses)
+}
+
+// StartPruner starts a background goroutine that, every [interval], forgets the
+// decisions that were made more than DecisionRetention ago. If a pruner is
+// already running, it is stopped first. The pruner only touches the decision
+// history, so it is safe to run alongside RecordPoll and Add.
+func (ta *Topological) StartPruner(interval time.Duration) {
+	ta.StopPruner()
+
+	ta.pruner = timer.NewRepeater(func() { ta.pruneDecisions() }, interval)
+	go ta.ctx.Log.RecoverAndPanic(ta.pruner.Dispatch)
+}
+
+// StopPruner halts the background pruner, if one is running, and waits for it
+// to exit.
+func (ta *Topological) StopPruner() {
+	if ta.pruner != nil {
+		ta.pruner.Stop()
+		ta.pruner = nil
+	}
+}
+
+// pruneDecisions forgets the decisions that are older than the retention
+// window and returns the number of decisions that were removed
+func (ta *Topological) pruneDecisions() int {
+	numPruned := ta.decisions.prune(ta.params.DecisionRetention)
+	if numPruned > 0 {
+		ta.ctx.Log.Debug("Pruned %d decisions older than %s", numPruned, ta.params.DecisionRetention)
+	}
+	return numPruned
+}
+
+// decided restarts the liveness watchdog's timeout and records the decision of
+// a vertex, if decisions are being retained
+func (ta *Topological) decided(vtxID ids.ID, status choices.Status) {
+	ta.resetLiveness()
+	if ta.params.DecisionRetention > 0 {
+		ta.decisions.add(vtxID, status)
+	}
+}
+
+// pruneAcceptedHistory remembers that [vtxID] was accepted and forgets the
+// decisions of the oldest accepted vertices beyond the newest
+// MaxHistoryVertices. Under a weak synchrony assumption, a vertex accepted that
+// long ago will no longer be voted for or issued by correct nodes, so its
+// decision doesn't need to be remembered. However, a vertex that still has
+// processing children is always remembered, so the accepted vertices are
+// forgotten in order only once they have no processing children.
+func (ta *Topological) pruneAcceptedHistory(vtxID ids.ID) {
+	max := ta.params.MaxHistoryVertices
+	if max <= 0 || ta.params.DecisionRetention <= 0 {
+		return
+	}
+
+	ta.acceptedHistory = append(ta.acceptedHistory, vtxID)
+	for len(ta.acceptedHistory) > max {
+		oldest := ta.acceptedHistory[0]
+		if ta.children[oldest.Key()].Len() > 0 {
+			return // The oldest vertex still has undecided descendants
+		}
+		ta.decisions.remove(oldest)
+		ta.acceptedHistory = ta.acceptedHistory[1:]
+	}
+}
@@ -0,0 +1,353 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+	"github.com/ava-labs/gecko/utils/timer"
+)
+
+func TestDecisionsPrune(t *testing.T) {
+	clock := timer.Clock{}
+	d := decisions{}
+	d.initialize(&clock)
+
+	now := time.Unix(1000, 0)
+	clock.Set(now)
+
+	vtxID0 := GenerateID()
+	vtxID1 := GenerateID()
+	d.add(vtxID0, choices.Accepted)
+
+	clock.Set(now.Add(time.Minute))
+	d.add(vtxID1, choices.Rejected)
+
+	if numPruned := d.prune(time.Minute); numPruned != 0 {
+		t.Fatalf("Shouldn't have pruned decisions inside the retention window, pruned %d", numPruned)
+	}
+
+	clock.Set(now.Add(time.Minute + time.Second))
+	if numPruned := d.prune(time.Minute); numPruned != 1 {
+		t.Fatalf("Should have pruned 1 decision, pruned %d", numPruned)
+	} else if _, ok := d.statuses[vtxID0.Key()]; ok {
+		t.Fatalf("Should have pruned the oldest decision")
+	} else if status := d.statuses[vtxID1.Key()]; status != choices.Rejected {
+		t.Fatalf("Wrong status. Expected %s got %s", choices.Rejected, status)
+	} else if d.len() != 1 {
+		t.Fatalf("Should have 1 remaining decision, has %d", d.len())
+	}
+}
+
+func TestDecisionsRemove(t *testing.T) {
+	d := decisions{}
+	d.initialize(&timer.Clock{})
+
+	vtxIDs := []ids.ID(nil)
+	for i := 0; i < 100; i++ {
+		vtxID := GenerateID()
+		d.add(vtxID, choices.Accepted)
+		vtxIDs = append(vtxIDs, vtxID)
+	}
+	for _, vtxID := range vtxIDs[:90] {
+		d.remove(vtxID)
+	}
+
+	if d.len() != 10 {
+		t.Fatalf("Should have 10 remaining decisions, has %d", d.len())
+	} else if len(d.order) > 2*d.len()+minDecisionsOrderSize {
+		t.Fatalf("Removed decisions should have been compacted, %d remain ordered", len(d.order))
+	} else if d.contains(vtxIDs[89]) {
+		t.Fatalf("Should have removed the decision")
+	} else if !d.contains(vtxIDs[90]) {
+		t.Fatalf("Shouldn't have removed the decision")
+	}
+}
+
+func TestAvalancheDecisionRetention(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:           2,
+		BatchSize:         1,
+		DecisionRetention: time.Minute,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	ta.RecordPoll(votes)
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if status := ta.decisions.statuses[vtx0.id.Key()]; status != choices.Accepted {
+		t.Fatalf("Wrong decision recorded. Expected %s got %s", choices.Accepted, status)
+	}
+
+	ta.clock.Set(time.Now().Add(2 * time.Minute))
+	if numPruned := ta.pruneDecisions(); numPruned != 1 {
+		t.Fatalf("Should have pruned 1 decision, pruned %d", numPruned)
+	}
+}
+
+// Should be run with -race to check that the pruner can run alongside polls
+func TestAvalanchePrunerConcurrentPolls(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:           2,
+		BatchSize:         1,
+		DecisionRetention: time.Nanosecond,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	ta.StartPruner(time.Microsecond)
+
+	parents := vts
+	for i := 0; i < 100; i++ {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+
+		vtx := &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       i + 1,
+			status:       choices.Processing,
+		}
+
+		ta.Add(vtx)
+
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx.id)
+		ta.RecordPoll(votes)
+
+		if vtx.Status() != choices.Accepted {
+			t.Fatalf("Vertex should have been accepted")
+		}
+		parents = []Vertex{vtx}
+	}
+
+	ta.StopPruner()
+	ta.StopPruner() // Stopping twice should be a no-op
+
+	time.Sleep(time.Millisecond)
+	ta.pruneDecisions()
+	if numDecisions := ta.decisions.len(); numDecisions != 0 {
+		t.Fatalf("All decisions should have been pruned, %d remain", numDecisions)
+	}
+}
+
+func TestAvalancheMaxHistoryVertices(t *testing.T) {
+	const maxHistory = 100
+
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		DecisionRetention:  time.Hour,
+		MaxHistoryVertices: maxHistory,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	parents := vts
+	vtxIDs := []ids.ID(nil)
+	for i := 0; i < 5000; i++ {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+
+		vtx := &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       i + 1,
+			status:       choices.Processing,
+		}
+
+		ta.Add(vtx)
+
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx.id)
+		ta.RecordPoll(votes)
+
+		if vtx.Status() != choices.Accepted {
+			t.Fatalf("Vertex should have been accepted")
+		} else if numDecisions := ta.decisions.len(); numDecisions > maxHistory {
+			t.Fatalf("At most %d decisions should be retained, %d are", maxHistory, numDecisions)
+		} else if numOrdered := len(ta.decisions.order); numOrdered > 2*maxHistory+minDecisionsOrderSize {
+			t.Fatalf("Pruned decisions should be compacted, %d are ordered", numOrdered)
+		}
+		parents = []Vertex{vtx}
+		vtxIDs = append(vtxIDs, vtx.id)
+	}
+
+	if len(ta.acceptedHistory) != maxHistory {
+		t.Fatalf("Expected %d accepted vertices in the history, got %d", maxHistory, len(ta.acceptedHistory))
+	}
+	for i, vtxID := range vtxIDs {
+		if retained := ta.decisions.contains(vtxID); retained != (i >= len(vtxIDs)-maxHistory) {
+			t.Fatalf("Vertex %d should only be retained if it is among the last %d accepted", i, maxHistory)
+		}
+	}
+}
+
+func TestAvalancheMaxHistoryVerticesRetainsLiveChildren(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		DecisionRetention:  time.Hour,
+		MaxHistoryVertices: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxo := GenerateID()
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	newVtx := func(parents []Vertex, inputID ids.ID) *Vtx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(inputID)
+
+		return &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       1,
+			status:       choices.Processing,
+		}
+	}
+	poll := func(vtx *Vtx) {
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx.id)
+		ta.RecordPoll(votes)
+	}
+
+	vtx0 := newVtx(vts, GenerateID())
+	// vtx1 is a child of vtx0 that conflicts with vtx2, so it stays processing
+	// until it receives BetaRogue votes
+	vtx1 := newVtx([]Vertex{vtx0}, utxo)
+	vtx2 := newVtx(vts, utxo)
+	vtx3 := newVtx(vts, GenerateID())
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+	poll(vtx0)
+	ta.Add(vtx3)
+	poll(vtx3)
+
+	if vtx0.Status() != choices.Accepted || vtx3.Status() != choices.Accepted {
+		t.Fatalf("Vertices should have been accepted")
+	} else if vtx1.Status() != choices.Processing {
+		t.Fatalf("Vertex should still be processing")
+	} else if !ta.decisions.contains(vtx0.id) {
+		t.Fatalf("Vertex with a processing child should be retained")
+	}
+
+	poll(vtx1)
+	poll(vtx1)
+
+	if vtx1.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if ta.decisions.contains(vtx0.id) || ta.decisions.contains(vtx3.id) {
+		t.Fatalf("Vertices without processing children should have been pruned")
+	} else if !ta.decisions.contains(vtx1.id) {
+		t.Fatalf("The most recently accepted vertex should be retained")
+	} else if status, _ := ta.decisions.status(vtx2.id); status != choices.Rejected {
+		t.Fatalf("Rejected vertices should still be retained")
+	}
+}
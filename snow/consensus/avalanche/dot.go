@@ -0,0 +1,76 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ava-labs/gecko/ids"
+)
+
+// dotIDLen is the number of characters of a vertex ID that label its node
+const dotIDLen = 8
+
+// ToDOT returns the live DAG in the GraphViz DOT format. Each live vertex is
+// labeled with the start of its ID and its status, and has an edge to each of
+// its parents. Decided parents are included, so the edges always have both
+// ends. Vertices in the preferred frontier are outlined in blue, vertices in
+// the virtuous frontier are filled in green, vertices that contain orphaned
+// transactions have a red label, and vertices without any descendents have a
+// double border.
+func (ta *Topological) ToDOT() string {
+	sb := strings.Builder{}
+	sb.WriteString("digraph avalanche {\n")
+
+	edges := []string(nil)
+	decidedParents := ids.Set{}
+	for _, vtx := range ta.Processing() {
+		vtxID := vtx.ID()
+		sb.WriteString(ta.dotNode(vtx))
+
+		for _, parent := range ta.parents(vtx) {
+			parentID := parent.ID()
+			edges = append(edges, fmt.Sprintf("\t%q -> %q;\n", vtxID, parentID))
+			if _, live := ta.nodes[parentID.Key()]; !live && !decidedParents.Contains(parentID) {
+				decidedParents.Add(parentID)
+				sb.WriteString(ta.dotNode(parent))
+			}
+		}
+	}
+	for _, edge := range edges {
+		sb.WriteString(edge)
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// dotNode returns the DOT node declaration of [vtx]
+func (ta *Topological) dotNode(vtx Vertex) string {
+	vtxID := vtx.ID()
+	key := vtxID.Key()
+
+	label := vtxID.String()
+	if len(label) > dotIDLen {
+		label = label[:dotIDLen]
+	}
+	attributes := []string{fmt.Sprintf("label=\"%s\\n%s\"", label, vtx.Status())}
+	if ta.preferred.Contains(vtxID) {
+		attributes = append(attributes, "color=blue")
+	}
+	if ta.virtuous.Contains(vtxID) {
+		attributes = append(attributes, "style=filled", "fillcolor=green")
+	}
+	for _, tx := range vtx.Txs() {
+		if ta.orphans.Contains(tx.ID()) {
+			attributes = append(attributes, "fontcolor=red")
+			break
+		}
+	}
+	if _, ok := ta.frontier[key]; ok {
+		attributes = append(attributes, "peripheries=2")
+	}
+	return fmt.Sprintf("\t%q [%s];\n", vtxID, strings.Join(attributes, ", "))
+}
@@ -0,0 +1,131 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+func TestAvalancheToDOT(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	utxo := GenerateID()
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxo)
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxo)
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	// tx2 is virtuous, but it isn't preferred as vtx1 isn't preferred
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(GenerateID())
+
+	vtx2 := &Vtx{
+		dependencies: []Vertex{vtx1},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       2,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	// Recording a poll recalculates the orphans
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	ta.RecordPoll(votes)
+
+	dot := ta.ToDOT()
+	if !strings.HasPrefix(dot, "digraph avalanche {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Fatalf("Malformed DOT graph:\n%s", dot)
+	}
+
+	node := func(vtx *Vtx, attributes ...string) string {
+		label := fmt.Sprintf("label=\"%s\\n%s\"", vtx.id.String()[:dotIDLen], vtx.status)
+		return fmt.Sprintf("\t%q [%s];\n", vtx.id, strings.Join(append([]string{label}, attributes...), ", "))
+	}
+	edge := func(child, parent Vertex) string {
+		return fmt.Sprintf("\t%q -> %q;\n", child.ID(), parent.ID())
+	}
+
+	expected := []string{
+		node(vts[0].(*Vtx), "style=filled", "fillcolor=green"),
+		node(vts[1].(*Vtx), "style=filled", "fillcolor=green"),
+		node(vtx0, "color=blue", "peripheries=2"),
+		node(vtx1),
+		node(vtx2, "fontcolor=red", "peripheries=2"),
+		edge(vtx0, vts[0]),
+		edge(vtx0, vts[1]),
+		edge(vtx1, vts[0]),
+		edge(vtx1, vts[1]),
+		edge(vtx2, vtx1),
+	}
+	for _, line := range expected {
+		if !strings.Contains(dot, line) {
+			t.Fatalf("DOT graph is missing %q:\n%s", line, dot)
+		}
+	}
+	if lines := strings.Count(dot, "\n"); lines != len(expected)+2 {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(expected)+2, lines, dot)
+	}
+}
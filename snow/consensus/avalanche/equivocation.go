@@ -0,0 +1,97 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"github.com/ava-labs/gecko/ids"
+)
+
+// recordEquivocationVotes remembers which validators voted for conflicting
+// vertices in their responses to this poll, forgetting polls that fall outside
+// of the equivocation window. voters[i] is the validator whose response was
+// recorded with index i in [responses]. The voted for vertices must still be
+// live when this is called.
+func (ta *Topological) recordEquivocationVotes(responses ids.UniqueBag, voters []ids.ShortID) {
+	window := ta.params.EquivocationWindow
+	if window <= 0 {
+		return
+	}
+
+	// Maps voter index -> inputID -> IDs of the txs the voter voted for that
+	// consume the input
+	spends := make([]map[[32]byte]ids.Set, len(voters))
+	equivocators := ids.ShortSet{}
+	for _, vote := range responses.List() {
+		vtx := ta.nodes[vote.Key()]
+		if vtx == nil {
+			continue // Conflicts can only be checked for live vertices
+		}
+		voteSet := responses.GetSet(vote)
+		for i, vdr := range voters {
+			if !voteSet.Contains(uint(i)) {
+				continue
+			}
+			if spends[i] == nil {
+				spends[i] = make(map[[32]byte]ids.Set)
+			}
+			for _, tx := range vtx.Txs() {
+				for _, inputID := range tx.InputIDs().List() {
+					inputKey := inputID.Key()
+					txIDs := spends[i][inputKey]
+					txIDs.Add(tx.ID())
+					spends[i][inputKey] = txIDs
+
+					// Voting for multiple txs that spend the same input in a
+					// single response means the validator voted for
+					// conflicting vertices
+					if txIDs.Len() > 1 {
+						equivocators.Add(vdr)
+					}
+				}
+			}
+		}
+	}
+
+	ta.recentEquivocators = append(ta.recentEquivocators, equivocators)
+	if len(ta.recentEquivocators) > window {
+		ta.recentEquivocators = ta.recentEquivocators[len(ta.recentEquivocators)-window:]
+	}
+}
+
+// Equivocators returns the validators that voted for conflicting vertices in a
+// single response to one of the last EquivocationWindow polls, sorted by ID.
+// Validators are only known for polls recorded with RecordPollFrom. Changing
+// preference between polls isn't an equivocation, so it isn't reported.
+func (ta *Topological) Equivocators() []ids.ShortID {
+	equivocators := ids.ShortSet{}
+	for _, pollEquivocators := range ta.recentEquivocators {
+		equivocators.Union(pollEquivocators)
+	}
+	vdrs := equivocators.List()
+	ids.SortShortIDs(vdrs)
+	return vdrs
+}
+
+// sameVertex returns true if [vtx0] and [vtx1] have the same parents and the
+// same transactions
+func (ta *Topological) sameVertex(vtx0, vtx1 Vertex) bool {
+	return ta.parentIDs(vtx0).Equals(ta.parentIDs(vtx1)) &&
+		txIDs(vtx0).Equals(txIDs(vtx1))
+}
+
+func (ta *Topological) parentIDs(vtx Vertex) ids.Set {
+	parentIDs := ids.Set{}
+	for _, parent := range ta.parents(vtx) {
+		parentIDs.Add(parent.ID())
+	}
+	return parentIDs
+}
+
+func txIDs(vtx Vertex) ids.Set {
+	txIDs := ids.Set{}
+	for _, tx := range vtx.Txs() {
+		txIDs.Add(tx.ID())
+	}
+	return txIDs
+}
@@ -0,0 +1,313 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+func TestAvalancheEquivocators(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 3,
+			Alpha:             3,
+			BetaVirtuous:      10,
+			BetaRogue:         10,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		EquivocationWindow: 3,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID(), GenerateID()}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxos[0])
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[0])
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(utxos[1])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx2 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	vdr0 := ids.NewShortID([20]byte{1})
+	vdr1 := ids.NewShortID([20]byte{2})
+	vdr2 := ids.NewShortID([20]byte{3})
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	votes.Add(1, vtx0.id)
+	votes.Add(2, vtx0.id)
+	ta.RecordPollFrom(votes, []ids.ShortID{vdr0, vdr1, vdr2})
+
+	if equivocators := ta.Equivocators(); len(equivocators) != 0 {
+		t.Fatalf("No validators should have equivocated yet, got %v", equivocators)
+	}
+
+	// Validator 1 changing its preference to the conflicting vtx1 isn't an
+	// equivocation
+	votes = ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	votes.Add(1, vtx1.id)
+	votes.Add(2, vtx2.id)
+	ta.RecordPollFrom(votes, []ids.ShortID{vdr0, vdr1, vdr2})
+
+	if equivocators := ta.Equivocators(); len(equivocators) != 0 {
+		t.Fatalf("Changing preference shouldn't be reported as equivocating, got %v", equivocators)
+	}
+
+	// Validator 0 votes for both conflicting vertices in one response. Its
+	// response has a different index than in the previous polls.
+	votes = ids.UniqueBag{}
+	votes.Add(0, vtx2.id)
+	votes.Add(1, vtx0.id, vtx1.id)
+	ta.RecordPollFrom(votes, []ids.ShortID{vdr2, vdr0})
+
+	if equivocators := ta.Equivocators(); len(equivocators) != 1 || !equivocators[0].Equals(vdr0) {
+		t.Fatalf("Validator 0 should have been reported as an equivocator, got %v", equivocators)
+	}
+
+	// After the window passes, the equivocations are forgotten
+	votes = ids.UniqueBag{}
+	votes.Add(0, vtx2.id)
+	ta.RecordPollFrom(votes, []ids.ShortID{vdr2})
+	ta.RecordPollFrom(votes, []ids.ShortID{vdr2})
+	ta.RecordPollFrom(votes, []ids.ShortID{vdr2})
+
+	if equivocators := ta.Equivocators(); len(equivocators) != 0 {
+		t.Fatalf("Equivocations outside of the window should be forgotten, got %v", equivocators)
+	}
+}
+
+func TestAvalancheEquivocatorsDroppedPoll(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      10,
+			BetaRogue:         10,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		EquivocationWindow: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxo := GenerateID()
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxo)
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxo)
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	vdr0 := ids.NewShortID([20]byte{1})
+	vdr1 := ids.NewShortID([20]byte{2})
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id, vtx1.id)
+	votes.Add(1, vtx0.id)
+	ta.RecordPollFrom(votes, []ids.ShortID{vdr0, vdr1})
+
+	if equivocators := ta.Equivocators(); len(equivocators) != 1 || !equivocators[0].Equals(vdr0) {
+		t.Fatalf("Validator 0 should have been reported as an equivocator, got %v", equivocators)
+	}
+
+	// The poll is dropped because it has a response from a validator that
+	// wasn't sampled, so it shouldn't take a slot in the window
+	votes = ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	votes.Add(1, vtx0.id, vtx1.id)
+	votes.Add(uint(params.K), vtx0.id)
+	ta.RecordPollFrom(votes, []ids.ShortID{vdr0, vdr1})
+
+	if equivocators := ta.Equivocators(); len(equivocators) != 1 || !equivocators[0].Equals(vdr0) {
+		t.Fatalf("A dropped poll shouldn't change the equivocators, got %v", equivocators)
+	}
+}
+
+func TestAvalancheVertexEquivocation(t *testing.T) {
+	sink := &recordingSink{}
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:     2,
+		BatchSize:   1,
+		MetricsSink: sink,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	if err := ta.AddChecked(vtx0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-adding the same contents, even in a different order, is ignored
+	duplicate := &Vtx{
+		dependencies: []Vertex{vts[1], vts[0]},
+		id:           vtx0.id,
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	if err := ta.AddChecked(duplicate); err != nil {
+		t.Fatalf("Re-adding the same vertex shouldn't error: %s", err)
+	}
+
+	reparented := &Vtx{
+		dependencies: vts[:1],
+		id:           vtx0.id,
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	if err := ta.AddChecked(reparented); err != ErrVertexEquivocation {
+		t.Fatalf("Expected %s, got %v", ErrVertexEquivocation, err)
+	}
+
+	retxed := &Vtx{
+		dependencies: vts,
+		id:           vtx0.id,
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+	if err := ta.AddChecked(retxed); err != ErrVertexEquivocation {
+		t.Fatalf("Expected %s, got %v", ErrVertexEquivocation, err)
+	}
+
+	if ta.nodes[vtx0.id.Key()] != vtx0 {
+		t.Fatalf("The originally added vertex should remain live")
+	} else if ta.TxIssued(tx1) {
+		t.Fatalf("Transactions of an equivocating vertex shouldn't be issued")
+	}
+
+	expected := []string{
+		"orphans 0",
+		fmt.Sprintf("issued %s", vtx0.id),
+		fmt.Sprintf("equivocated %s", vtx0.id),
+		fmt.Sprintf("equivocated %s", vtx0.id),
+	}
+	if !reflect.DeepEqual(sink.events, expected) {
+		t.Fatalf("Expected events %v, got %v", expected, sink.events)
+	}
+}
@@ -0,0 +1,242 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+)
+
+// responseLen is the number of bytes each response is encoded with by
+// MarshalResponses: the vertex ID followed by the bit set of its voters
+const responseLen = idLen + 8
+
+var (
+	errReplayNotInitialized = errors.New("the first replayed event must initialize the instance")
+	errMalformedResponses   = errors.New("responses are malformed")
+	errMissingVertexBytes   = errors.New("vertex bytes are missing")
+	errNoVoteDecay          = errors.New("weighted polls can't be replayed without a vote decay")
+)
+
+// EventType identifies the call that an Event records
+type EventType uint8
+
+// Event types
+const (
+	InitializeEvent EventType = iota
+	AddEvent
+	RecordPollEvent
+	RecordWeightedPollEvent
+	RecordPollWithIDEvent
+)
+
+func (t EventType) String() string {
+	switch t {
+	case InitializeEvent:
+		return "Initialize"
+	case AddEvent:
+		return "Add"
+	case RecordPollEvent:
+		return "RecordPoll"
+	case RecordWeightedPollEvent:
+		return "RecordWeightedPoll"
+	case RecordPollWithIDEvent:
+		return "RecordPollWithID"
+	default:
+		return fmt.Sprintf("Unknown(%d)", uint8(t))
+	}
+}
+
+// Event is a call that changed the state of an instance
+type Event struct {
+	Type EventType
+
+	// Context and Parameters are the arguments of an InitializeEvent
+	Context    *snow.Context
+	Parameters Parameters
+
+	// VertexBytes are the bytes of the accepted frontier of an
+	// InitializeEvent, or of the single vertex of an AddEvent
+	VertexBytes [][]byte
+
+	// Responses are the responses of a poll event, encoded with
+	// MarshalResponses
+	Responses []byte
+
+	// Voters are the validators of the responses of a RecordPollEvent, if they
+	// were provided with RecordPollFrom
+	Voters []ids.ShortID
+
+	// Weight is the number of times the responses of a
+	// RecordWeightedPollEvent were applied to the conflict graph
+	Weight int
+
+	// PollID is the ID of a RecordPollWithIDEvent. Repeated deliveries of a
+	// poll aren't recorded, as they are ignored.
+	PollID uint32
+}
+
+// EventRecorder is notified of each event of an instance, in the order that
+// the events occurred, so that the instance can be reproduced with Replay
+type EventRecorder interface {
+	Record(Event)
+}
+
+// Replay initializes a new instance from [factory] and applies [events] to it
+// in order. The first event must be an InitializeEvent. The replayed instance
+// doesn't record its own events, and reports its metrics to a new registry so
+// that it doesn't conflict with the recorded instance.
+//
+// The vertices of the events are rebuilt from their VertexBytes with
+// [parseVertex], which must return vertices with the status they had when the
+// event was recorded. Weighted polls and polls recorded with an ID can only be
+// replayed into a Topological instance.
+func Replay(factory Factory, parseVertex func([]byte) (Vertex, error), events []Event) (Consensus, error) {
+	if len(events) == 0 || events[0].Type != InitializeEvent {
+		return nil, errReplayNotInitialized
+	}
+
+	params := events[0].Parameters
+	params.Metrics = prometheus.NewRegistry()
+	params.MetricsSink = nil
+	params.EventRecorder = nil
+
+	frontier, err := parseVertices(parseVertex, events[0].VertexBytes)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse the vertices of event 0 due to %w", err)
+	}
+	consensus := factory.New()
+	if err := consensus.Initialize(events[0].Context, params, frontier); err != nil {
+		return nil, err
+	}
+
+	for i, event := range events[1:] {
+		if err := replay(consensus, parseVertex, event); err != nil {
+			return nil, fmt.Errorf("couldn't replay event %d due to %w", i+1, err)
+		}
+	}
+	return consensus, nil
+}
+
+// replay applies [event], other than an InitializeEvent, to [consensus]
+func replay(consensus Consensus, parseVertex func([]byte) (Vertex, error), event Event) error {
+	if event.Type == AddEvent {
+		vts, err := parseVertices(parseVertex, event.VertexBytes)
+		if err != nil {
+			return err
+		}
+		for _, vtx := range vts {
+			consensus.Add(vtx)
+		}
+		return nil
+	}
+
+	responses, err := ParseResponses(event.Responses)
+	if err != nil {
+		return err
+	}
+	ta, isTopological := consensus.(*Topological)
+	switch {
+	case event.Type == RecordPollEvent:
+		consensus.RecordPollFrom(responses, event.Voters)
+	case event.Type != RecordWeightedPollEvent && event.Type != RecordPollWithIDEvent:
+		return fmt.Errorf("unexpected event type %s", event.Type)
+	case !isTopological:
+		return fmt.Errorf("%s events can't be replayed into %T", event.Type, consensus)
+	case event.Type == RecordWeightedPollEvent && ta.params.VoteDecay == 0:
+		return errNoVoteDecay
+	case event.Type == RecordWeightedPollEvent:
+		if weight := ta.nextPollWeight(); weight != event.Weight {
+			return fmt.Errorf("poll weight %d differs from the recorded weight %d", weight, event.Weight)
+		}
+		ta.recordPoll(responses, nil, event.Weight)
+	default:
+		ta.RecordPollWithID(event.PollID, responses)
+	}
+	return nil
+}
+
+// parseVertices parses each of [vtxBytes] with [parseVertex]
+func parseVertices(parseVertex func([]byte) (Vertex, error), vtxBytes [][]byte) ([]Vertex, error) {
+	vts := make([]Vertex, len(vtxBytes))
+	for i, b := range vtxBytes {
+		if len(b) == 0 {
+			return nil, errMissingVertexBytes
+		}
+		vtx, err := parseVertex(b)
+		if err != nil {
+			return nil, err
+		}
+		vts[i] = vtx
+	}
+	return vts, nil
+}
+
+// MarshalResponses encodes [responses] as the varint number of vertices,
+// followed by each vertex ID and the big endian bit set of its voters, sorted
+// by vertex ID
+func MarshalResponses(responses ids.UniqueBag) []byte {
+	vtxIDs := responses.List()
+	ids.SortIDs(vtxIDs)
+
+	buf := bytes.Buffer{}
+	writeUvarint(&buf, uint64(len(vtxIDs)))
+	for _, vtxID := range vtxIDs {
+		buf.Write(vtxID.Bytes())
+		voters := [8]byte{}
+		binary.BigEndian.PutUint64(voters[:], uint64(responses.GetSet(vtxID)))
+		buf.Write(voters[:])
+	}
+	return buf.Bytes()
+}
+
+// ParseResponses is the inverse of MarshalResponses
+func ParseResponses(b []byte) (ids.UniqueBag, error) {
+	count, n := binary.Uvarint(b)
+	if n <= 0 {
+		return nil, errMalformedResponses
+	}
+	b = b[n:]
+	if count != uint64(len(b)/responseLen) || len(b)%responseLen != 0 {
+		return nil, errMalformedResponses
+	}
+
+	responses := ids.UniqueBag{}
+	for ; len(b) > 0; b = b[responseLen:] {
+		vtxID := [idLen]byte{}
+		copy(vtxID[:], b)
+		voters := ids.BitSet(binary.BigEndian.Uint64(b[idLen:responseLen]))
+		responses.UnionSet(ids.NewID(vtxID), voters)
+	}
+	return responses, nil
+}
+
+// recordAdd records that [vtx] is being added, if an EventRecorder was
+// provided
+func (ta *Topological) recordAdd(vtx Vertex) {
+	if ta.params.EventRecorder == nil {
+		return
+	}
+	ta.params.EventRecorder.Record(Event{
+		Type:        AddEvent,
+		VertexBytes: [][]byte{vtx.Bytes()},
+	})
+}
+
+// recordPollEvent records [event] with [responses], if an EventRecorder was
+// provided
+func (ta *Topological) recordPollEvent(event Event, responses ids.UniqueBag) {
+	if ta.params.EventRecorder == nil {
+		return
+	}
+	event.Responses = MarshalResponses(responses)
+	ta.params.EventRecorder.Record(event)
+}
@@ -0,0 +1,352 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+var errUnknownVertex = errors.New("unknown vertex")
+
+type eventLog []Event
+
+func (l *eventLog) Record(event Event) { *l = append(*l, event) }
+
+// replayDAG returns a function that builds a new copy of the same genesis and
+// processing vertices each time it is called, so that a recorded session can
+// be replayed with undecided vertices
+func replayDAG() func() ([]Vertex, []*Vtx) {
+	genesisIDs := []ids.ID{GenerateID(), GenerateID()}
+	vtxIDs := []ids.ID{GenerateID(), GenerateID(), GenerateID()}
+	txIDs := []ids.ID{GenerateID(), GenerateID(), GenerateID()}
+	utxos := []ids.ID{GenerateID(), GenerateID()}
+
+	return func() ([]Vertex, []*Vtx) {
+		genesis := []Vertex{&Vtx{
+			id:     genesisIDs[0],
+			status: choices.Accepted,
+			bytes:  []byte{10},
+		}, &Vtx{
+			id:     genesisIDs[1],
+			status: choices.Accepted,
+			bytes:  []byte{11},
+		}}
+
+		txs := make([]*snowstorm.TestTx, len(txIDs))
+		for i, txID := range txIDs {
+			txs[i] = &snowstorm.TestTx{
+				Identifier: txID,
+				Stat:       choices.Processing,
+			}
+		}
+		// tx0 and tx1 conflict, tx2 is virtuous
+		txs[0].Ins.Add(utxos[0])
+		txs[1].Ins.Add(utxos[0])
+		txs[2].Ins.Add(utxos[1])
+
+		vtx0 := &Vtx{
+			dependencies: genesis,
+			id:           vtxIDs[0],
+			txs:          []snowstorm.Tx{txs[0]},
+			height:       1,
+			status:       choices.Processing,
+			bytes:        []byte{0},
+		}
+		vtx1 := &Vtx{
+			dependencies: genesis,
+			id:           vtxIDs[1],
+			txs:          []snowstorm.Tx{txs[1]},
+			height:       1,
+			status:       choices.Processing,
+			bytes:        []byte{1},
+		}
+		vtx2 := &Vtx{
+			dependencies: []Vertex{vtx0},
+			id:           vtxIDs[2],
+			txs:          []snowstorm.Tx{txs[2]},
+			height:       2,
+			status:       choices.Processing,
+			bytes:        []byte{2},
+		}
+		return genesis, []*Vtx{vtx0, vtx1, vtx2}
+	}
+}
+
+// parser returns a function that parses the vertices of [genesis] and [vts]
+// from their bytes
+func parser(genesis []Vertex, vts []*Vtx) func([]byte) (Vertex, error) {
+	byBytes := make(map[string]Vertex)
+	for _, vtx := range genesis {
+		byBytes[string(vtx.Bytes())] = vtx
+	}
+	for _, vtx := range vts {
+		byBytes[string(vtx.Bytes())] = vtx
+	}
+	return func(b []byte) (Vertex, error) {
+		vtx, ok := byBytes[string(b)]
+		if !ok {
+			return nil, errUnknownVertex
+		}
+		return vtx, nil
+	}
+}
+
+func TestReplay(t *testing.T) {
+	log := eventLog{}
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:       2,
+		BatchSize:     1,
+		EventRecorder: &log,
+	}
+	build := replayDAG()
+	genesis, vts := build()
+
+	ta := Topological{}
+	if err := ta.Initialize(snow.DefaultContextTest(), params, genesis); err != nil {
+		t.Fatal(err)
+	}
+	for _, vtx := range vts {
+		ta.Add(vtx)
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vts[2].id)
+	votes.Add(1, vts[2].id)
+	ta.RecordPoll(votes)
+
+	// The partial session is replayed before the instance finalizes
+	numPartialEvents := len(log)
+	partialPreferences := ids.Set{}
+	partialPreferences.Union(ta.Preferences())
+	if ta.Finalized() {
+		t.Fatalf("An avalanche instance finalized too early")
+	}
+
+	ta.RecordPoll(votes)
+	if !ta.Finalized() {
+		t.Fatalf("An avalanche instance should have finalized")
+	}
+
+	expectedTypes := []EventType{InitializeEvent, AddEvent, AddEvent, AddEvent, RecordPollEvent, RecordPollEvent}
+	if len(log) != len(expectedTypes) {
+		t.Fatalf("Expected %d events, got %d", len(expectedTypes), len(log))
+	}
+	for i, event := range log {
+		if event.Type != expectedTypes[i] {
+			t.Fatalf("Event %d should have been %s, got %s", i, expectedTypes[i], event.Type)
+		}
+	}
+	if addBytes := log[1].VertexBytes; len(addBytes) != 1 || len(addBytes[0]) != 1 || addBytes[0][0] != 0 {
+		t.Fatalf("Wrong vertex bytes recorded %v", addBytes)
+	}
+
+	partialGenesis, partialVts := build()
+	partial, err := Replay(TopologicalFactory{}, parser(partialGenesis, partialVts), log[:numPartialEvents])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefs := partial.Preferences(); !prefs.Equals(partialPreferences) {
+		t.Fatalf("Expected preferences %s, got %s", partialPreferences, prefs)
+	} else if partial.Finalized() {
+		t.Fatalf("The partially replayed instance shouldn't have finalized")
+	}
+
+	fullGenesis, fullVts := build()
+	full, err := Replay(TopologicalFactory{}, parser(fullGenesis, fullVts), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefs, expected := full.Preferences(), ta.Preferences(); !prefs.Equals(expected) {
+		t.Fatalf("Expected preferences %s, got %s", expected, prefs)
+	} else if !full.Finalized() {
+		t.Fatalf("The replayed instance should have finalized")
+	}
+	for i, vtx := range fullVts {
+		if status, expected := vtx.Status(), vts[i].Status(); status != expected {
+			t.Fatalf("Replayed vertex %d should be %s, got %s", i, expected, status)
+		}
+	}
+
+	if len(log) != len(expectedTypes) {
+		t.Fatalf("Replaying shouldn't have recorded any events")
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	genesis, vts := replayDAG()()
+	parse := parser(genesis, vts)
+	if _, err := Replay(TopologicalFactory{}, parse, nil); err == nil {
+		t.Fatalf("Should have errored without an initialization event")
+	} else if _, err := Replay(TopologicalFactory{}, parse, []Event{{Type: AddEvent}}); err == nil {
+		t.Fatalf("Should have errored without an initialization event")
+	}
+
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+		VoteDecay: 1,
+	}
+	initialize := Event{
+		Type:        InitializeEvent,
+		Context:     snow.DefaultContextTest(),
+		Parameters:  params,
+		VertexBytes: [][]byte{genesis[0].Bytes(), genesis[1].Bytes()},
+	}
+	events := []Event{initialize, {
+		Type:      RecordPollEvent,
+		Responses: []byte{1},
+	}}
+	if _, err := Replay(TopologicalFactory{}, parse, events); err == nil {
+		t.Fatalf("Should have errored on malformed responses")
+	}
+
+	events = []Event{initialize, {
+		Type:        AddEvent,
+		VertexBytes: [][]byte{{3}},
+	}}
+	if _, err := Replay(TopologicalFactory{}, parse, events); err == nil {
+		t.Fatalf("Should have errored on an unknown vertex")
+	}
+
+	events = []Event{initialize, {
+		Type:      RecordWeightedPollEvent,
+		Responses: MarshalResponses(ids.UniqueBag{}),
+		Weight:    2,
+	}}
+	if _, err := Replay(TopologicalFactory{}, parse, events); err == nil {
+		t.Fatalf("Should have errored on a weight that differs from the replayed weight")
+	}
+}
+
+func TestReplayPolls(t *testing.T) {
+	log := eventLog{}
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      3,
+			BetaRogue:         5,
+			ConcurrentRepolls: 1,
+		},
+		Parents:             2,
+		BatchSize:           1,
+		VoteDecay:           0.4,
+		ParticipationWindow: 10,
+		EventRecorder:       &log,
+	}
+	build := replayDAG()
+	genesis, vts := build()
+
+	ta := Topological{}
+	if err := ta.Initialize(snow.DefaultContextTest(), params, genesis); err != nil {
+		t.Fatal(err)
+	}
+	for _, vtx := range vts {
+		ta.Add(vtx)
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vts[2].id)
+	votes.Add(1, vts[2].id)
+	voters := []ids.ShortID{ids.NewShortID([20]byte{1}), ids.NewShortID([20]byte{2})}
+
+	ta.RecordPollFrom(votes, voters)
+	ta.RecordWeightedPoll(votes)
+	if !ta.RecordPollWithID(7, votes) {
+		t.Fatalf("The poll should have been recorded")
+	} else if ta.RecordPollWithID(7, votes) {
+		t.Fatalf("The repeated poll should have been ignored")
+	}
+
+	expectedTypes := []EventType{InitializeEvent, AddEvent, AddEvent, AddEvent, RecordPollEvent, RecordWeightedPollEvent, RecordPollWithIDEvent}
+	if len(log) != len(expectedTypes) {
+		t.Fatalf("Expected %d events, got %d", len(expectedTypes), len(log))
+	}
+	for i, event := range log {
+		if event.Type != expectedTypes[i] {
+			t.Fatalf("Event %d should have been %s, got %s", i, expectedTypes[i], event.Type)
+		}
+	}
+	if recorded := log[4].Voters; len(recorded) != len(voters) {
+		t.Fatalf("Expected %d voters, got %d", len(voters), len(recorded))
+	} else if weight := log[5].Weight; weight != 2 {
+		t.Fatalf("Expected weight %d, got %d", 2, weight)
+	} else if pollID := log[6].PollID; pollID != 7 {
+		t.Fatalf("Expected poll ID %d, got %d", 7, pollID)
+	}
+
+	replayedGenesis, replayedVts := build()
+	replayed, err := Replay(TopologicalFactory{}, parser(replayedGenesis, replayedVts), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, vtx := range replayedVts {
+		if status, expected := vtx.Status(), vts[i].Status(); status != expected {
+			t.Fatalf("Replayed vertex %d should be %s, got %s", i, expected, status)
+		}
+	}
+	if prefs, expected := replayed.Preferences(), ta.Preferences(); !prefs.Equals(expected) {
+		t.Fatalf("Expected preferences %s, got %s", expected, prefs)
+	}
+
+	replayedTA := replayed.(*Topological)
+	participation := ta.Participation()
+	replayedParticipation := replayedTA.Participation()
+	if len(replayedParticipation) != len(voters) {
+		t.Fatalf("Expected participation of %d voters, got %d", len(voters), len(replayedParticipation))
+	}
+	for key, expected := range participation {
+		if rate := replayedParticipation[key]; rate != expected {
+			t.Fatalf("Expected participation %f, got %f", expected, rate)
+		}
+	}
+	if replayedTA.voteCredit != ta.voteCredit {
+		t.Fatalf("Expected vote credit %f, got %f", ta.voteCredit, replayedTA.voteCredit)
+	} else if replayedTA.RecordPollWithID(7, votes) {
+		t.Fatalf("The replayed instance should have ignored the repeated poll")
+	}
+}
+
+func TestMarshalResponses(t *testing.T) {
+	responses := ids.UniqueBag{}
+	responses.Add(0, GenerateID(), GenerateID())
+	responses.Add(63, GenerateID())
+
+	parsed, err := ParseResponses(MarshalResponses(responses))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equals(responses) {
+		t.Fatalf("Expected responses %s, got %s", &responses, &parsed)
+	}
+
+	if _, err := ParseResponses(nil); err == nil {
+		t.Fatalf("Should have errored on empty responses")
+	}
+}
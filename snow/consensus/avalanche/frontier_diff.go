@@ -0,0 +1,73 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"errors"
+
+	"github.com/ava-labs/gecko/ids"
+)
+
+// ErrFullSyncRequired is returned by AcceptedFrontierDiff when the marker isn't
+// among the remembered accepted vertices, so the accepted frontier must be
+// synced in full
+var ErrFullSyncRequired = errors.New("marker isn't among the recently accepted vertices")
+
+// acceptedLog remembers the IDs of the most recently accepted vertices, in the
+// order they were accepted
+type acceptedLog struct {
+	// Maximum number of vertices to remember
+	size int
+	// IDs of the remembered vertices, oldest first
+	vtxIDs []ids.ID
+	// Maps vtxID -> acceptance sequence number of the remembered vertex
+	sequences map[[32]byte]uint64
+	// Acceptance sequence number of the first remembered vertex
+	first uint64
+}
+
+func (l *acceptedLog) initialize(size int, sequence uint64) {
+	l.size = size
+	l.vtxIDs = nil
+	l.sequences = make(map[[32]byte]uint64)
+	l.first = sequence + 1
+}
+
+// add remembers that [vtxID] was accepted with [sequence], which must follow
+// the sequence of the previously added vertex
+func (l *acceptedLog) add(vtxID ids.ID, sequence uint64) {
+	if l.size <= 0 {
+		l.first = sequence + 1
+		return
+	}
+
+	if len(l.vtxIDs) == l.size {
+		delete(l.sequences, l.vtxIDs[0].Key())
+		l.vtxIDs = l.vtxIDs[1:]
+		l.first++
+	}
+	l.vtxIDs = append(l.vtxIDs, vtxID)
+	l.sequences[vtxID.Key()] = sequence
+}
+
+// AcceptedFrontierDiff returns the IDs of the vertices accepted after the
+// vertex [since], in the order they were accepted. If [since] is ids.Empty,
+// every vertex accepted by this instance is returned. Only the last
+// AcceptedLogSize accepted vertices are remembered, so if [since] was accepted
+// before them, or wasn't accepted by this instance, ErrFullSyncRequired is
+// returned.
+func (ta *Topological) AcceptedFrontierDiff(since ids.ID) ([]ids.ID, error) {
+	accepted := &ta.acceptedLog
+	start := accepted.first
+	if !since.Equals(ids.Empty) {
+		sequence, ok := accepted.sequences[since.Key()]
+		if !ok {
+			return nil, ErrFullSyncRequired
+		}
+		start = sequence + 1
+	} else if start != 1 {
+		return nil, ErrFullSyncRequired // The earliest vertices were forgotten
+	}
+	return append([]ids.ID(nil), accepted.vtxIDs[start-accepted.first:]...), nil
+}
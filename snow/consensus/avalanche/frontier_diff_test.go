@@ -0,0 +1,110 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+func TestAvalancheAcceptedFrontierDiff(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:         2,
+		BatchSize:       1,
+		AcceptedLogSize: 3,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	if diff, err := ta.AcceptedFrontierDiff(ids.Empty); err != nil {
+		t.Fatal(err)
+	} else if len(diff) != 0 {
+		t.Fatalf("Nothing has been accepted, but got diff %v", diff)
+	}
+
+	// Accepts a chain of [n] vertices and returns their IDs
+	parents := vts
+	acceptChain := func(n int) []ids.ID {
+		vtxIDs := []ids.ID(nil)
+		for i := 0; i < n; i++ {
+			tx := &snowstorm.TestTx{
+				Identifier: GenerateID(),
+				Stat:       choices.Processing,
+			}
+			tx.Ins.Add(GenerateID())
+
+			vtx := &Vtx{
+				dependencies: parents,
+				id:           GenerateID(),
+				txs:          []snowstorm.Tx{tx},
+				height:       1,
+				status:       choices.Processing,
+			}
+			ta.Add(vtx)
+
+			votes := ids.UniqueBag{}
+			votes.Add(0, vtx.id)
+			ta.RecordPoll(votes)
+
+			if vtx.Status() != choices.Accepted {
+				t.Fatalf("Vertex should have been accepted")
+			}
+			parents = []Vertex{vtx}
+			vtxIDs = append(vtxIDs, vtx.id)
+		}
+		return vtxIDs
+	}
+
+	accepted := acceptChain(2)
+	if diff, err := ta.AcceptedFrontierDiff(ids.Empty); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(diff, accepted) {
+		t.Fatalf("Expected diff %v, got %v", accepted, diff)
+	}
+
+	marker := accepted[1]
+	newlyAccepted := acceptChain(2)
+	if diff, err := ta.AcceptedFrontierDiff(marker); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(diff, newlyAccepted) {
+		t.Fatalf("Expected diff %v, got %v", newlyAccepted, diff)
+	}
+
+	if diff, err := ta.AcceptedFrontierDiff(newlyAccepted[1]); err != nil {
+		t.Fatal(err)
+	} else if len(diff) != 0 {
+		t.Fatalf("Nothing was accepted after the marker, but got diff %v", diff)
+	}
+
+	// Only the last 3 accepted vertices are remembered
+	for _, since := range []ids.ID{ids.Empty, accepted[0], GenerateID()} {
+		if _, err := ta.AcceptedFrontierDiff(since); err != ErrFullSyncRequired {
+			t.Fatalf("Expected %s for marker %s, got %v", ErrFullSyncRequired, since, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"fmt"
+	"time"
+)
+
+// health tracks the progress of consensus that HealthCheck reports on
+type health struct {
+	// Time a vertex was most recently accepted, or the time the instance was
+	// initialized if no vertex has been accepted
+	lastAccept time.Time
+	// Time the number of processing vertices most recently rose above
+	// HealthMaxProcessing
+	overloadedSince time.Time
+}
+
+// HealthCheck returns details about the progress of consensus. An error is
+// also returned if more than HealthMaxProcessing vertices have been processing
+// for at least HealthStallTimeout without any vertex being accepted. If
+// HealthStallTimeout isn't positive, no error is ever returned.
+func (ta *Topological) HealthCheck() (interface{}, error) {
+	now := ta.clock.Time()
+	sinceLastAccept := now.Sub(ta.health.lastAccept)
+	details := map[string]interface{}{
+		"processingVertices":  len(ta.nodes),
+		"orphans":             ta.orphans.Len(),
+		"timeSinceLastAccept": sinceLastAccept.String(),
+	}
+
+	timeout := ta.params.HealthStallTimeout
+	switch {
+	case timeout <= 0 || len(ta.nodes) <= ta.params.HealthMaxProcessing:
+		return details, nil
+	case sinceLastAccept < timeout || now.Sub(ta.health.overloadedSince) < timeout:
+		return details, nil
+	default:
+		return details, fmt.Errorf("%d vertices are processing, but no vertex has been accepted for %s",
+			len(ta.nodes), sinceLastAccept)
+	}
+}
+
+// processingIncreased records when the number of processing vertices rises
+// above HealthMaxProcessing. It must be called whenever a vertex starts
+// processing.
+func (ta *Topological) processingIncreased() {
+	if len(ta.nodes) == ta.params.HealthMaxProcessing+1 {
+		ta.health.overloadedSince = ta.clock.Time()
+	}
+}
@@ -0,0 +1,139 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+// newHealthTest returns an instance initialized at [now] that reports an error
+// once more than 2 vertices have been processing for a minute without any
+// vertex being accepted
+func newHealthTest(now time.Time) (*Topological, []Vertex) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:             2,
+		BatchSize:           1,
+		HealthMaxProcessing: 2,
+		HealthStallTimeout:  time.Minute,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := &Topological{}
+	ta.clock.Set(now)
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+	return ta, vts
+}
+
+func newHealthVtx(parents []Vertex) *Vtx {
+	tx := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx.Ins.Add(GenerateID())
+
+	return &Vtx{
+		dependencies: parents,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx},
+		height:       1,
+		status:       choices.Processing,
+	}
+}
+
+func TestAvalancheHealthCheckStalled(t *testing.T) {
+	now := time.Unix(1000, 0)
+	ta, vts := newHealthTest(now)
+
+	// A long idle period before the vertices arrive isn't a stall
+	ta.clock.Set(now.Add(time.Hour))
+	for i := 0; i < 3; i++ {
+		ta.Add(newHealthVtx(vts))
+	}
+
+	ta.clock.Set(now.Add(time.Hour + 59*time.Second))
+	if _, err := ta.HealthCheck(); err != nil {
+		t.Fatalf("Shouldn't be unhealthy before the stall timeout, got %s", err)
+	}
+
+	ta.clock.Set(now.Add(time.Hour + time.Minute))
+	details, err := ta.HealthCheck()
+	if err == nil {
+		t.Fatalf("Should be unhealthy after the stall timeout")
+	}
+
+	detailsMap, ok := details.(map[string]interface{})
+	switch {
+	case !ok:
+		t.Fatalf("Wrong details type %T", details)
+	case detailsMap["processingVertices"] != 3:
+		t.Fatalf("Expected %d processing vertices, got %v", 3, detailsMap["processingVertices"])
+	case detailsMap["orphans"] != 0:
+		t.Fatalf("Expected %d orphans, got %v", 0, detailsMap["orphans"])
+	case detailsMap["timeSinceLastAccept"] != (time.Hour + time.Minute).String():
+		t.Fatalf("Expected %s since the last accept, got %v", time.Hour+time.Minute, detailsMap["timeSinceLastAccept"])
+	}
+}
+
+func TestAvalancheHealthCheckChurn(t *testing.T) {
+	now := time.Unix(1000, 0)
+	ta, vts := newHealthTest(now)
+
+	processing := []*Vtx(nil)
+	for i := 0; i < 3; i++ {
+		vtx := newHealthVtx(vts)
+		ta.Add(vtx)
+		processing = append(processing, vtx)
+	}
+
+	// A vertex is accepted every 30 seconds, so there is always a backlog of
+	// processing vertices without consensus being stalled
+	for i := 1; i <= 10; i++ {
+		ta.clock.Set(now.Add(time.Duration(i) * 30 * time.Second))
+
+		votes := ids.UniqueBag{}
+		votes.Add(0, processing[0].id)
+		ta.RecordPoll(votes)
+
+		if status := processing[0].Status(); status != choices.Accepted {
+			t.Fatalf("Vertex should have been accepted, but is %s", status)
+		}
+
+		vtx := newHealthVtx(vts)
+		ta.Add(vtx)
+		processing = append(processing[1:], vtx)
+
+		if _, err := ta.HealthCheck(); err != nil {
+			t.Fatalf("Shouldn't be unhealthy while vertices are being accepted, got %s", err)
+		}
+	}
+
+	// Consensus stalls once vertices stop being accepted
+	ta.clock.Set(now.Add(5*time.Minute + time.Minute))
+	if _, err := ta.HealthCheck(); err == nil {
+		t.Fatalf("Should be unhealthy after the stall timeout")
+	}
+}
@@ -0,0 +1,36 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"time"
+)
+
+// SetLivenessWatchdog registers [f] to be called if polls keep being recorded
+// for [timeout] without any vertex being decided. The timeout restarts
+// whenever a vertex is decided and after [f] is called, so [f] is called once
+// every [timeout] for as long as consensus remains stalled. Time spent without
+// any processing vertices doesn't count towards the timeout. Passing a
+// non-positive timeout or a nil callback disables the watchdog.
+func (ta *Topological) SetLivenessWatchdog(timeout time.Duration, f func()) {
+	ta.livenessTimeout = timeout
+	ta.onLivenessTimeout = f
+	ta.resetLiveness()
+}
+
+// resetLiveness restarts the liveness watchdog's timeout
+func (ta *Topological) resetLiveness() { ta.livenessStart = ta.clock.Time() }
+
+// checkLiveness calls the liveness watchdog's callback if its timeout has
+// elapsed while there were processing vertices
+func (ta *Topological) checkLiveness() {
+	switch {
+	case ta.livenessTimeout <= 0 || ta.onLivenessTimeout == nil:
+	case len(ta.nodes) == 0:
+		ta.resetLiveness() // Consensus can't stall with nothing to decide
+	case ta.clock.Time().Sub(ta.livenessStart) >= ta.livenessTimeout:
+		ta.resetLiveness()
+		ta.onLivenessTimeout()
+	}
+}
@@ -0,0 +1,122 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+func TestAvalancheLivenessWatchdog(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      2,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	now := time.Unix(1000, 0)
+	ta.clock.Set(now)
+
+	timeouts := 0
+	ta.SetLivenessWatchdog(time.Minute, func() { timeouts++ })
+
+	newVtx := func() *Vtx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+
+		return &Vtx{
+			dependencies: vts,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       1,
+			status:       choices.Processing,
+		}
+	}
+	poll := func(elapsed time.Duration, vtxIDs ...ids.ID) {
+		ta.clock.Set(now.Add(elapsed))
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtxIDs...)
+		ta.RecordPoll(votes)
+	}
+
+	// Without any processing vertices, consensus can't stall
+	poll(2 * time.Minute)
+	if timeouts != 0 {
+		t.Fatalf("Watchdog shouldn't fire without processing vertices")
+	}
+
+	vtx0 := newVtx()
+	ta.Add(vtx0)
+
+	poll(2*time.Minute + 59*time.Second)
+	if timeouts != 0 {
+		t.Fatalf("Watchdog shouldn't fire before the timeout")
+	}
+
+	poll(3 * time.Minute)
+	if timeouts != 1 {
+		t.Fatalf("Watchdog should have fired once, fired %d times", timeouts)
+	}
+
+	poll(3*time.Minute+30*time.Second, vtx0.id)
+	if timeouts != 1 {
+		t.Fatalf("Watchdog should restart its timeout after firing")
+	}
+
+	// Finalizing a vertex counts as progress
+	poll(4*time.Minute+30*time.Second, vtx0.id)
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if timeouts != 1 {
+		t.Fatalf("Watchdog shouldn't fire when a vertex is finalized")
+	}
+
+	vtx1 := newVtx()
+	ta.Add(vtx1)
+
+	poll(5*time.Minute + 29*time.Second)
+	if timeouts != 1 {
+		t.Fatalf("Watchdog shouldn't fire before the timeout since the last finalization")
+	}
+
+	poll(5*time.Minute + 30*time.Second)
+	if timeouts != 2 {
+		t.Fatalf("Watchdog should have fired twice, fired %d times", timeouts)
+	}
+
+	// Disabling the watchdog stops the callbacks
+	ta.SetLivenessWatchdog(0, nil)
+	poll(10 * time.Minute)
+	if timeouts != 2 {
+		t.Fatalf("Disabled watchdog shouldn't fire")
+	}
+}
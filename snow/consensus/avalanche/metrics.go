@@ -14,11 +14,77 @@ import (
 	"github.com/ava-labs/gecko/utils/timer"
 )
 
+// MetricsSink is notified of the events that the consensus instance reports
+// metrics on. It allows metrics to be reported without Prometheus.
+type MetricsSink interface {
+	// Issued is called when a vertex starts processing
+	Issued(vtxID ids.ID)
+	// Accepted is called when a processing vertex is accepted
+	Accepted(vtxID ids.ID)
+	// Rejected is called when a processing vertex is rejected
+	Rejected(vtxID ids.ID)
+	// Removed is called when a processing vertex is evicted without being
+	// decided
+	Removed(vtxID ids.ID)
+	// WastedVotes is called with the number of votes in a poll that were for
+	// decided or unknown vertices
+	WastedVotes(numVotes int)
+	// LateVotes is called with the number of votes in a poll that were for
+	// vertices known to have been decided. These votes are also wasted.
+	LateVotes(numVotes int)
+	// Equivocated is called when a vertex is added with the same ID as a
+	// processing vertex, but with different contents
+	Equivocated(vtxID ids.ID)
+	// Orphans is called with the number of virtuous transactions that aren't
+	// preferred whenever the frontiers are recalculated
+	Orphans(numOrphans int)
+	// ObservePollLatency is called with how long it took to record each poll
+	ObservePollLatency(latency time.Duration)
+}
+
+// NoMetrics is a MetricsSink that drops all events
+type NoMetrics struct{}
+
+// Issued implements the MetricsSink interface
+func (NoMetrics) Issued(ids.ID) {}
+
+// Accepted implements the MetricsSink interface
+func (NoMetrics) Accepted(ids.ID) {}
+
+// Rejected implements the MetricsSink interface
+func (NoMetrics) Rejected(ids.ID) {}
+
+// Removed implements the MetricsSink interface
+func (NoMetrics) Removed(ids.ID) {}
+
+// WastedVotes implements the MetricsSink interface
+func (NoMetrics) WastedVotes(int) {}
+
+// LateVotes implements the MetricsSink interface
+func (NoMetrics) LateVotes(int) {}
+
+// Equivocated implements the MetricsSink interface
+func (NoMetrics) Equivocated(ids.ID) {}
+
+// Orphans implements the MetricsSink interface
+func (NoMetrics) Orphans(int) {}
+
+// ObservePollLatency implements the MetricsSink interface
+func (NoMetrics) ObservePollLatency(time.Duration) {}
+
+// metrics is the MetricsSink that reports to Prometheus
 type metrics struct {
 	numProcessing            prometheus.Gauge
 	latAccepted, latRejected prometheus.Histogram
-
-	clock      timer.Clock
+	numWastedVotes           prometheus.Counter
+	numLateVotes             prometheus.Counter
+	numEquivocations         prometheus.Counter
+	numOrphans               prometheus.Gauge
+	latPolls                 prometheus.Histogram
+
+	// clock is the clock of the instance, which decision latencies are
+	// measured with
+	clock      *timer.Clock
 	processing map[[32]byte]time.Time
 }
 
@@ -47,6 +113,38 @@ func (m *metrics) Initialize(log logging.Logger, namespace string, registerer pr
 			Buckets:   timer.Buckets,
 		})
 
+	m.numWastedVotes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "vtx_wasted_votes",
+			Help:      "Number of votes dropped because they were for decided or unknown vertices",
+		})
+	m.numLateVotes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "vtx_late_votes",
+			Help:      "Number of votes dropped because they were for vertices that were already decided",
+		})
+	m.numEquivocations = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "vtx_equivocations",
+			Help:      "Number of vertices dropped because they differed from a processing vertex with the same ID",
+		})
+	m.numOrphans = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "vtx_orphans",
+			Help:      "Number of virtuous transactions that aren't preferred",
+		})
+	m.latPolls = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "vtx_poll_latency",
+			Help:      "Latency of recording a poll in milliseconds",
+			Buckets:   timer.Buckets,
+		})
+
 	if err := registerer.Register(m.numProcessing); err != nil {
 		return fmt.Errorf("Failed to register vtx_processing statistics due to %w", err)
 	}
@@ -56,6 +154,21 @@ func (m *metrics) Initialize(log logging.Logger, namespace string, registerer pr
 	if err := registerer.Register(m.latRejected); err != nil {
 		return fmt.Errorf("Failed to register vtx_rejected statistics due to %w", err)
 	}
+	if err := registerer.Register(m.numWastedVotes); err != nil {
+		return fmt.Errorf("Failed to register vtx_wasted_votes statistics due to %w", err)
+	}
+	if err := registerer.Register(m.numLateVotes); err != nil {
+		return fmt.Errorf("Failed to register vtx_late_votes statistics due to %w", err)
+	}
+	if err := registerer.Register(m.numEquivocations); err != nil {
+		return fmt.Errorf("Failed to register vtx_equivocations statistics due to %w", err)
+	}
+	if err := registerer.Register(m.numOrphans); err != nil {
+		return fmt.Errorf("Failed to register vtx_orphans statistics due to %w", err)
+	}
+	if err := registerer.Register(m.latPolls); err != nil {
+		return fmt.Errorf("Failed to register vtx_poll_latency statistics due to %w", err)
+	}
 	return nil
 }
 
@@ -85,3 +198,20 @@ func (m *metrics) Rejected(id ids.ID) {
 	m.latRejected.Observe(float64(end.Sub(start).Milliseconds()))
 	m.numProcessing.Dec()
 }
+
+func (m *metrics) Removed(id ids.ID) {
+	delete(m.processing, id.Key())
+	m.numProcessing.Dec()
+}
+
+func (m *metrics) WastedVotes(numVotes int) { m.numWastedVotes.Add(float64(numVotes)) }
+
+func (m *metrics) LateVotes(numVotes int) { m.numLateVotes.Add(float64(numVotes)) }
+
+func (m *metrics) Equivocated(ids.ID) { m.numEquivocations.Inc() }
+
+func (m *metrics) Orphans(numOrphans int) { m.numOrphans.Set(float64(numOrphans)) }
+
+func (m *metrics) ObservePollLatency(latency time.Duration) {
+	m.latPolls.Observe(float64(latency.Milliseconds()))
+}
@@ -0,0 +1,316 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+	"github.com/ava-labs/gecko/utils/logging"
+	"github.com/ava-labs/gecko/utils/timer"
+)
+
+type recordingSink struct{ events []string }
+
+func (s *recordingSink) Issued(vtxID ids.ID)   { s.record("issued", vtxID) }
+func (s *recordingSink) Accepted(vtxID ids.ID) { s.record("accepted", vtxID) }
+func (s *recordingSink) Rejected(vtxID ids.ID) { s.record("rejected", vtxID) }
+func (s *recordingSink) Removed(vtxID ids.ID)  { s.record("removed", vtxID) }
+func (s *recordingSink) Equivocated(vtxID ids.ID) {
+	s.record("equivocated", vtxID)
+}
+func (s *recordingSink) WastedVotes(numVotes int) {
+	s.events = append(s.events, fmt.Sprintf("wasted %d", numVotes))
+}
+func (s *recordingSink) LateVotes(numVotes int) {
+	s.events = append(s.events, fmt.Sprintf("late %d", numVotes))
+}
+func (s *recordingSink) Orphans(numOrphans int) {
+	s.events = append(s.events, fmt.Sprintf("orphans %d", numOrphans))
+}
+
+func (s *recordingSink) ObservePollLatency(latency time.Duration) {
+	s.events = append(s.events, fmt.Sprintf("poll %s", latency))
+}
+func (s *recordingSink) record(event string, vtxID ids.ID) {
+	s.events = append(s.events, fmt.Sprintf("%s %s", event, vtxID))
+}
+
+func TestMetricsSink(t *testing.T) {
+	sink := &recordingSink{}
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:     2,
+		BatchSize:   1,
+		MetricsSink: sink,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID()}
+
+	ta := Topological{}
+	if err := ta.Initialize(snow.DefaultContextTest(), params, vts); err != nil {
+		t.Fatal(err)
+	}
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxos[0])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[0])
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	// The clock is frozen so that the poll latency is deterministic
+	ta.clock.Set(time.Unix(1000, 0))
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id, vts[0].ID())
+	ta.RecordPoll(votes)
+
+	expected := []string{
+		"orphans 0",
+		fmt.Sprintf("issued %s", vtx0.id),
+		fmt.Sprintf("issued %s", vtx1.id),
+		"wasted 1",
+		"late 0",
+		fmt.Sprintf("accepted %s", vtx0.id),
+		fmt.Sprintf("rejected %s", vtx1.id),
+		"orphans 0",
+		"poll 0s",
+	}
+	if len(sink.events) != len(expected) {
+		t.Fatalf("Wrong events. Expected %v got %v", expected, sink.events)
+	}
+
+	// The order that vertices are decided in during a poll is unspecified
+	decided := map[string]bool{expected[5]: true, expected[6]: true}
+	for i, event := range sink.events {
+		if (i < 5 || i > 6) && event != expected[i] {
+			t.Fatalf("Wrong event %d. Expected %s got %s", i, expected[i], event)
+		} else if i >= 5 && i <= 6 && !decided[event] {
+			t.Fatalf("Unexpected event %d: %s", i, event)
+		}
+	}
+}
+
+func TestNoMetrics(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:     2,
+		BatchSize:   1,
+		MetricsSink: NoMetrics{},
+	}
+
+	// No Prometheus registerer is provided, so this would fail if Prometheus
+	// metrics were initialized
+	ta := Topological{}
+	if err := ta.Initialize(snow.DefaultContextTest(), params, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMetricsDecisionLatency(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	clock := timer.Clock{}
+	m := &metrics{clock: &clock}
+	if err := m.Initialize(logging.NoLog{}, "", registry); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1000, 0)
+	clock.Set(now)
+
+	vtxID0 := GenerateID()
+	vtxID1 := GenerateID()
+	m.Issued(vtxID0)
+	m.Issued(vtxID1)
+
+	clock.Set(now.Add(5 * time.Millisecond))
+	m.Accepted(vtxID0)
+
+	clock.Set(now.Add(8 * time.Millisecond))
+	m.Rejected(vtxID1)
+
+	m.ObservePollLatency(3 * time.Millisecond)
+
+	if len(m.processing) != 0 {
+		t.Fatalf("Decided vertices should no longer be tracked, but %d are", len(m.processing))
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	latencies := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			if histogram := metric.GetHistogram(); histogram != nil {
+				if count := histogram.GetSampleCount(); count != 1 {
+					t.Fatalf("Expected %s to have %d observation, got %d", family.GetName(), 1, count)
+				}
+				latencies[family.GetName()] = histogram.GetSampleSum()
+			}
+		}
+	}
+
+	if latency := latencies["vtx_accepted"]; latency != 5 {
+		t.Fatalf("Expected an accept latency of %dms, got %fms", 5, latency)
+	} else if latency := latencies["vtx_rejected"]; latency != 8 {
+		t.Fatalf("Expected a reject latency of %dms, got %fms", 8, latency)
+	} else if latency := latencies["vtx_poll_latency"]; latency != 3 {
+		t.Fatalf("Expected a poll latency of %dms, got %fms", 3, latency)
+	}
+}
+
+func TestMetricsOrphans(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           registry,
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	if err := ta.Initialize(snow.DefaultContextTest(), params, vts); err != nil {
+		t.Fatal(err)
+	}
+
+	utxo := GenerateID()
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxo)
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxo)
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	// tx2 is virtuous, but it isn't preferred as vtx1 isn't preferred
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(GenerateID())
+
+	vtx2 := &Vtx{
+		dependencies: []Vertex{vtx1},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       2,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	ta.RecordPoll(votes)
+
+	if orphans := ta.Orphans(); !orphans.Contains(tx2.ID()) {
+		t.Fatalf("Tx should have been orphaned")
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, family := range families {
+		if family.GetName() != "vtx_orphans" {
+			continue
+		}
+		found = true
+		if orphans := family.GetMetric()[0].GetGauge().GetValue(); orphans < 1 {
+			t.Fatalf("Expected at least %d orphans, got %f", 1, orphans)
+		}
+	}
+	if !found {
+		t.Fatalf("Orphans gauge wasn't registered")
+	}
+}
@@ -5,7 +5,9 @@ package avalanche
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow/consensus/snowball"
 )
 
@@ -14,6 +16,118 @@ import (
 type Parameters struct {
 	snowball.Parameters
 	Parents, BatchSize int
+
+	// AlphaFraction, if positive, replaces Alpha with the fraction of the
+	// validators that responded to a poll that must vote for a transaction for
+	// its confidence to increase.
+	AlphaFraction float64
+
+	// DecisionRetention is how long the statuses of decided vertices are
+	// remembered for. If zero, decided vertices are forgotten immediately.
+	DecisionRetention time.Duration
+
+	// MaxHistoryVertices, if positive, is the maximum number of accepted
+	// vertices whose decisions are retained, in addition to the accepted
+	// vertices that still have processing children.
+	MaxHistoryVertices int
+
+	// MaxLiveVertices, if positive, is the maximum number of vertices that may
+	// be processing at once.
+	MaxLiveVertices int
+
+	// MaxProcessingDepth, if positive, is the maximum number of processing
+	// vertices in any chain of parents, including the last vertex of the
+	// chain. This bounds the recursion needed to update a new vertex.
+	MaxProcessingDepth int
+
+	// MaxVtxSize, if positive, is the maximum number of bytes a vertex may be
+	// to be added.
+	MaxVtxSize int
+
+	// HealthMaxProcessing is the number of processing vertices that HealthCheck
+	// tolerates without any vertex being accepted
+	HealthMaxProcessing int
+
+	// HealthStallTimeout is how long more than HealthMaxProcessing vertices
+	// may be processing without any vertex being accepted before HealthCheck
+	// reports an error. If zero, HealthCheck never reports an error.
+	HealthStallTimeout time.Duration
+
+	// EquivocationWindow is the number of recent polls in which a validator
+	// voting for conflicting vertices in a single response is reported as an
+	// equivocator. If zero, equivocations aren't tracked.
+	EquivocationWindow int
+
+	// ParticipationWindow is the number of recent polls over which the
+	// participation of each validator is measured. If zero, participation
+	// isn't tracked.
+	ParticipationWindow int
+
+	// MetricsSink, if non-nil, is notified of vertex metric events instead of
+	// them being reported to Prometheus through Metrics. If Metrics is also
+	// nil, the transaction metrics of the conflict graph are dropped.
+	MetricsSink MetricsSink
+
+	// VoteDecay, if non-zero, is the factor by which each poll recorded with
+	// RecordWeightedPoll discounts the polls before it. Smaller factors
+	// finalize transactions in fewer polls, at the cost of safety, although a
+	// single poll never finalizes a transaction unless BetaVirtuous is 1.
+	VoteDecay float64
+
+	// AcceptedFilterSize is the number of bits in the Bloom filter of accepted
+	// vertices used by ProbablyAccepted. Larger filters report fewer false
+	// positives. If zero, accepted vertices aren't tracked.
+	AcceptedFilterSize int
+
+	// AcceptedLogSize is the number of most recently accepted vertices that
+	// are remembered so that AcceptedFrontierDiff can report the vertices
+	// accepted after them. If zero, every diff requires a full sync.
+	AcceptedLogSize int
+
+	// VirtuousFirst, if true, causes the vertices that become acceptable in a
+	// poll to be accepted with the virtuous vertices before the vertices
+	// containing rogue transactions. The set of accepted vertices is unchanged.
+	VirtuousFirst bool
+
+	// VoteHistorySize is the number of recent polls for which the votes of
+	// each processing transaction are remembered. If zero, vote histories
+	// aren't recorded.
+	VoteHistorySize int
+
+	// DecidedVoteHistories is the number of transactions whose vote histories
+	// are kept after they stop processing. The histories of the transactions
+	// that stopped processing least recently are forgotten first. If zero, a
+	// history is forgotten once its transaction stops processing.
+	DecidedVoteHistories int
+
+	// VerifyFrontiers, if true, recomputes the frontiers from every live vertex
+	// after each poll and reports any difference from the incrementally
+	// updated frontiers as a fatal error. This is a debugging aid for test
+	// networks; it makes every poll traverse the entire live set.
+	VerifyFrontiers bool
+
+	// Tracer, if non-nil, is used to trace the phases of recording polls
+	Tracer Tracer
+
+	// OnAccept, if non-nil, is called with the ID and bytes of each vertex
+	// this instance accepts, after the vertex was accepted and the consensus
+	// dispatcher was notified, along with the vertex's acceptance sequence
+	// number. Vertices are reported in the order that they were accepted. The
+	// first accepted vertex has sequence number 1 and each following vertex
+	// has the next sequence number, so a sink that records the last sequence
+	// number it persisted can ignore vertices that are replayed after a
+	// restart.
+	OnAccept func(vtxID ids.ID, bytes []byte, sequence uint64)
+
+	// OnReject, if non-nil, is called with the ID and bytes of each vertex
+	// this instance rejects, after the vertex was rejected, along with one of
+	// the RejectReason values
+	OnReject func(vtxID ids.ID, bytes []byte, reason string)
+
+	// EventRecorder, if non-nil, is notified of the initialization of the
+	// instance and of every vertex added and poll recorded, so that the
+	// instance can be reproduced with Replay
+	EventRecorder EventRecorder
 }
 
 // Valid returns nil if the parameters describe a valid initialization.
@@ -23,7 +137,45 @@ func (p Parameters) Valid() error {
 		return fmt.Errorf("parents = %d: Fails the condition that: 1 < Parents", p.Parents)
 	case p.BatchSize <= 0:
 		return fmt.Errorf("batchSize = %d: Fails the condition that: 0 < BatchSize", p.BatchSize)
+	case p.AlphaFraction != 0 && (p.AlphaFraction <= .5 || p.AlphaFraction > 1):
+		return fmt.Errorf("alphaFraction = %f: Fails the condition that: 0.5 < AlphaFraction <= 1", p.AlphaFraction)
+	case p.DecisionRetention < 0:
+		return fmt.Errorf("decisionRetention = %s: Fails the condition that: 0 <= DecisionRetention", p.DecisionRetention)
+	case p.HealthMaxProcessing < 0:
+		return fmt.Errorf("healthMaxProcessing = %d: Fails the condition that: 0 <= HealthMaxProcessing", p.HealthMaxProcessing)
+	case p.HealthStallTimeout < 0:
+		return fmt.Errorf("healthStallTimeout = %s: Fails the condition that: 0 <= HealthStallTimeout", p.HealthStallTimeout)
+	case p.EquivocationWindow < 0:
+		return fmt.Errorf("equivocationWindow = %d: Fails the condition that: 0 <= EquivocationWindow", p.EquivocationWindow)
+	case p.ParticipationWindow < 0:
+		return fmt.Errorf("participationWindow = %d: Fails the condition that: 0 <= ParticipationWindow", p.ParticipationWindow)
+	case p.MaxHistoryVertices < 0:
+		return fmt.Errorf("maxHistoryVertices = %d: Fails the condition that: 0 <= MaxHistoryVertices", p.MaxHistoryVertices)
+	case p.MaxProcessingDepth < 0:
+		return fmt.Errorf("maxProcessingDepth = %d: Fails the condition that: 0 <= MaxProcessingDepth", p.MaxProcessingDepth)
+	case p.MaxVtxSize < 0:
+		return fmt.Errorf("maxVtxSize = %d: Fails the condition that: 0 <= MaxVtxSize", p.MaxVtxSize)
+	case p.MaxLiveVertices < 0:
+		return fmt.Errorf("maxLiveVertices = %d: Fails the condition that: 0 <= MaxLiveVertices", p.MaxLiveVertices)
+	case p.VoteDecay != 0 && (p.VoteDecay <= 0 || p.VoteDecay > 1):
+		return fmt.Errorf("voteDecay = %f: Fails the condition that: 0 < VoteDecay <= 1", p.VoteDecay)
+	case p.AcceptedFilterSize < 0:
+		return fmt.Errorf("acceptedFilterSize = %d: Fails the condition that: 0 <= AcceptedFilterSize", p.AcceptedFilterSize)
+	case p.AcceptedLogSize < 0:
+		return fmt.Errorf("acceptedLogSize = %d: Fails the condition that: 0 <= AcceptedLogSize", p.AcceptedLogSize)
+	case p.VoteHistorySize < 0:
+		return fmt.Errorf("voteHistorySize = %d: Fails the condition that: 0 <= VoteHistorySize", p.VoteHistorySize)
+	case p.DecidedVoteHistories < 0:
+		return fmt.Errorf("decidedVoteHistories = %d: Fails the condition that: 0 <= DecidedVoteHistories", p.DecidedVoteHistories)
 	default:
 		return p.Parameters.Valid()
 	}
 }
+
+// String returns the thresholds of the parameters on a single line
+func (p Parameters) String() string {
+	return fmt.Sprintf("Namespace=%q K=%d Alpha=%d BetaVirtuous=%d BetaRogue=%d ConcurrentRepolls=%d "+
+		"Parents=%d BatchSize=%d AlphaFraction=%g DecisionRetention=%s MaxLiveVertices=%d MaxVtxSize=%d",
+		p.Namespace, p.K, p.Alpha, p.BetaVirtuous, p.BetaRogue, p.ConcurrentRepolls,
+		p.Parents, p.BatchSize, p.AlphaFraction, p.DecisionRetention, p.MaxLiveVertices, p.MaxVtxSize)
+}
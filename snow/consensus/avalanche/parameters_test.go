@@ -4,7 +4,9 @@
 package avalanche
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ava-labs/gecko/snow/consensus/snowball"
 )
@@ -62,3 +64,351 @@ func TestParametersInvalidBatchSize(t *testing.T) {
 		t.Fatalf("Should have failed due to invalid batch size")
 	}
 }
+
+func TestParametersInvalidAlphaFraction(t *testing.T) {
+	for _, alphaFraction := range []float64{-1, .5, 1.5} {
+		p := Parameters{
+			Parameters: snowball.Parameters{
+				K:                 1,
+				Alpha:             1,
+				BetaVirtuous:      1,
+				BetaRogue:         1,
+				ConcurrentRepolls: 1,
+			},
+			Parents:       2,
+			BatchSize:     1,
+			AlphaFraction: alphaFraction,
+		}
+
+		if err := p.Valid(); err == nil {
+			t.Fatalf("Should have failed due to invalid alpha fraction %f", alphaFraction)
+		}
+	}
+}
+
+func TestParametersInvalidDecisionRetention(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:           2,
+		BatchSize:         1,
+		DecisionRetention: -time.Second,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid decision retention")
+	}
+}
+
+func TestParametersInvalidMaxLiveVertices(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:         2,
+		BatchSize:       1,
+		MaxLiveVertices: -1,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid max live vertices")
+	}
+}
+
+func TestParametersInvalidMaxVtxSize(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:    2,
+		BatchSize:  1,
+		MaxVtxSize: -1,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid max vertex size")
+	}
+}
+
+func TestParametersInvalidVoteDecay(t *testing.T) {
+	for _, voteDecay := range []float64{-.5, 1.5} {
+		p := Parameters{
+			Parameters: snowball.Parameters{
+				K:                 1,
+				Alpha:             1,
+				BetaVirtuous:      1,
+				BetaRogue:         1,
+				ConcurrentRepolls: 1,
+			},
+			Parents:   2,
+			BatchSize: 1,
+			VoteDecay: voteDecay,
+		}
+
+		if err := p.Valid(); err == nil {
+			t.Fatalf("Should have failed due to invalid vote decay %f", voteDecay)
+		}
+	}
+}
+
+func TestParametersInvalidAcceptedFilterSize(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		AcceptedFilterSize: -1,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid accepted filter size")
+	}
+}
+
+func TestParametersInvalidVoteHistorySize(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:         2,
+		BatchSize:       1,
+		VoteHistorySize: -1,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid vote history size")
+	}
+}
+
+func TestParametersInvalidDecidedVoteHistories(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:              2,
+		BatchSize:            1,
+		DecidedVoteHistories: -1,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid number of decided vote histories")
+	}
+}
+
+func TestParametersInvalidParticipationWindow(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:             2,
+		BatchSize:           1,
+		ParticipationWindow: -1,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid participation window")
+	}
+}
+
+func TestParametersInvalidAcceptedLogSize(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:         2,
+		BatchSize:       1,
+		AcceptedLogSize: -1,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid accepted log size")
+	}
+}
+
+func TestParametersInvalidMaxHistoryVertices(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		MaxHistoryVertices: -1,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid max history vertices")
+	}
+}
+
+func TestParametersInvalidHealthMaxProcessing(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:             2,
+		BatchSize:           1,
+		HealthMaxProcessing: -1,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid health max processing")
+	}
+}
+
+func TestParametersInvalidHealthStallTimeout(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		HealthStallTimeout: -time.Second,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid health stall timeout")
+	}
+}
+
+func TestParametersString(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			Namespace:         "avalanche",
+			K:                 20,
+			Alpha:             14,
+			BetaVirtuous:      15,
+			BetaRogue:         30,
+			ConcurrentRepolls: 4,
+		},
+		Parents:           5,
+		BatchSize:         30,
+		AlphaFraction:     .75,
+		DecisionRetention: time.Minute,
+		MaxLiveVertices:   1000,
+		MaxVtxSize:        1 << 20,
+	}
+
+	str := p.String()
+	for _, expected := range []string{
+		`Namespace="avalanche"`,
+		"K=20",
+		"Alpha=14",
+		"BetaVirtuous=15",
+		"BetaRogue=30",
+		"ConcurrentRepolls=4",
+		"Parents=5",
+		"BatchSize=30",
+		"AlphaFraction=0.75",
+		"DecisionRetention=1m0s",
+		"MaxLiveVertices=1000",
+		"MaxVtxSize=1048576",
+	} {
+		if !strings.Contains(str, expected) {
+			t.Fatalf("Expected %q to contain %q", str, expected)
+		}
+	}
+	if strings.Contains(str, "\n") {
+		t.Fatalf("Expected %q to be a single line", str)
+	}
+}
+
+func TestParametersInvalidParentsAndAlphaErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		k, alpha int
+		parents  int
+		expected string
+	}{
+		{"alpha at half of k", 4, 2, 2, "K = 4, Alpha = 2: Fails the condition that: K/2 < Alpha"},
+		{"alpha below half of k", 5, 1, 2, "K = 5, Alpha = 1: Fails the condition that: K/2 < Alpha"},
+		{"alpha above k", 3, 4, 2, "K = 3, Alpha = 4: Fails the condition that: Alpha <= K"},
+		{"single parent", 1, 1, 1, "parents = 1: Fails the condition that: 1 < Parents"},
+		{"no parents", 1, 1, 0, "parents = 0: Fails the condition that: 1 < Parents"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Parameters{
+				Parameters: snowball.Parameters{
+					K:                 tt.k,
+					Alpha:             tt.alpha,
+					BetaVirtuous:      1,
+					BetaRogue:         1,
+					ConcurrentRepolls: 1,
+				},
+				Parents:   tt.parents,
+				BatchSize: 1,
+			}
+
+			if err := p.Valid(); err == nil {
+				t.Fatalf("Should have failed with %q", tt.expected)
+			} else if err.Error() != tt.expected {
+				t.Fatalf("Expected error %q, got %q", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestParametersInvalidMaxProcessingDepth(t *testing.T) {
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		MaxProcessingDepth: -1,
+	}
+
+	if err := p.Valid(); err == nil {
+		t.Fatalf("Should have failed due to invalid max processing depth")
+	}
+}
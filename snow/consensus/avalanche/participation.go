@@ -0,0 +1,68 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"github.com/ava-labs/gecko/ids"
+)
+
+// recordParticipation remembers which validators were polled and which of them
+// responded, forgetting polls that fall outside of the participation window.
+// voters[i] is the validator whose response was recorded with index i in
+// [responses].
+func (ta *Topological) recordParticipation(responses ids.UniqueBag, voters []ids.ShortID) {
+	window := ta.params.ParticipationWindow
+	if window <= 0 {
+		return
+	}
+
+	responded := ids.BitSet(0)
+	for _, voteSet := range responses {
+		responded.Union(voteSet)
+	}
+
+	polled := ids.ShortSet{}
+	responders := ids.ShortSet{}
+	for i, vdr := range voters {
+		polled.Add(vdr)
+		if responded.Contains(uint(i)) {
+			responders.Add(vdr)
+		}
+	}
+
+	ta.recentPolled = append(ta.recentPolled, polled)
+	ta.recentResponders = append(ta.recentResponders, responders)
+	if len(ta.recentPolled) > window {
+		ta.recentPolled = ta.recentPolled[len(ta.recentPolled)-window:]
+		ta.recentResponders = ta.recentResponders[len(ta.recentResponders)-window:]
+	}
+}
+
+// Participation returns, for each validator polled within the last
+// ParticipationWindow polls, the fraction of those polls that the validator
+// voted in. Validators are only known for polls recorded with RecordPollFrom.
+// Validators that rarely vote when polled are likely unresponsive. Returns nil
+// if participation isn't tracked or no validators have been polled.
+func (ta *Topological) Participation() map[[20]byte]float64 {
+	numPolls := make(map[[20]byte]int)
+	numResponses := make(map[[20]byte]int)
+	for i, polled := range ta.recentPolled {
+		for _, vdr := range polled.List() {
+			key := vdr.Key()
+			numPolls[key]++
+			if ta.recentResponders[i].Contains(vdr) {
+				numResponses[key]++
+			}
+		}
+	}
+	if len(numPolls) == 0 {
+		return nil
+	}
+
+	participation := make(map[[20]byte]float64, len(numPolls))
+	for key, polls := range numPolls {
+		participation[key] = float64(numResponses[key]) / float64(polls)
+	}
+	return participation
+}
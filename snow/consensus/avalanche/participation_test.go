@@ -0,0 +1,135 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+func TestAvalancheParticipation(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 4,
+			Alpha:             3,
+			BetaVirtuous:      100,
+			BetaRogue:         100,
+			ConcurrentRepolls: 1,
+		},
+		Parents:             2,
+		BatchSize:           1,
+		ParticipationWindow: 4,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	if participation := ta.Participation(); participation != nil {
+		t.Fatalf("Participation shouldn't be reported before any polls")
+	}
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	ta.Add(vtx0)
+
+	vdrs := []ids.ShortID{
+		ids.NewShortID([20]byte{1}),
+		ids.NewShortID([20]byte{2}),
+		ids.NewShortID([20]byte{3}),
+		ids.NewShortID([20]byte{4}),
+	}
+
+	// Validator 3 never responds and validator 2 only responds to the first
+	// poll, which eventually falls outside of the window
+	for i := 0; i < 6; i++ {
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx0.id)
+		votes.Add(1, vtx0.id)
+		if i == 0 {
+			votes.Add(2, vtx0.id)
+		}
+		ta.RecordPollFrom(votes, vdrs)
+	}
+
+	participation := ta.Participation()
+	expected := map[[20]byte]float64{
+		vdrs[0].Key(): 1,
+		vdrs[1].Key(): 1,
+		vdrs[2].Key(): 0,
+		vdrs[3].Key(): 0,
+	}
+	if len(participation) != len(expected) {
+		t.Fatalf("Expected participation of %d validators, got %d", len(expected), len(participation))
+	}
+	for key, rate := range expected {
+		if participation[key] != rate {
+			t.Fatalf("Expected validator %s to have participation %f, got %f", ids.NewShortID(key), rate, participation[key])
+		}
+	}
+
+	// Validator 2 isn't polled, and validator 1 stops responding. The
+	// validators respond with different indices than in the earlier polls.
+	for i := 0; i < 2; i++ {
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx0.id)
+		votes.Add(2, vtx0.id)
+		ta.RecordPollFrom(votes, []ids.ShortID{vdrs[3], vdrs[1], vdrs[0]})
+	}
+
+	participation = ta.Participation()
+	expected = map[[20]byte]float64{
+		vdrs[0].Key(): 1,
+		vdrs[1].Key(): .5,
+		vdrs[2].Key(): 0,
+		vdrs[3].Key(): .5,
+	}
+	if len(participation) != len(expected) {
+		t.Fatalf("Expected participation of %d validators, got %d", len(expected), len(participation))
+	}
+	for key, rate := range expected {
+		if participation[key] != rate {
+			t.Fatalf("Expected validator %s to have participation %f, got %f", ids.NewShortID(key), rate, participation[key])
+		}
+	}
+
+	// The poll is dropped because it has a response from a validator that
+	// wasn't sampled, so it isn't counted
+	votes := ids.UniqueBag{}
+	votes.Add(2, vtx0.id)
+	votes.Add(uint(params.K), vtx0.id)
+	ta.RecordPollFrom(votes, vdrs)
+
+	participation = ta.Participation()
+	for key, rate := range expected {
+		if participation[key] != rate {
+			t.Fatalf("A dropped poll shouldn't change the participation of validator %s, got %f", ids.NewShortID(key), participation[key])
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+)
+
+// Names of the built-in parameter profiles
+const (
+	DefaultProfile      = "default"
+	LowLatencyProfile   = "low-latency"
+	HighSecurityProfile = "high-security"
+)
+
+var (
+	profilesLock sync.RWMutex
+	profiles     = map[string]Parameters{
+		// Matches the default command line parameters of a node
+		DefaultProfile: {
+			Parameters: snowball.Parameters{
+				K:                 5,
+				Alpha:             4,
+				BetaVirtuous:      20,
+				BetaRogue:         30,
+				ConcurrentRepolls: 1,
+			},
+			Parents:   5,
+			BatchSize: 30,
+		},
+		// Finalizes in fewer rounds, at the cost of a lower safety margin
+		LowLatencyProfile: {
+			Parameters: snowball.Parameters{
+				K:                 5,
+				Alpha:             4,
+				BetaVirtuous:      10,
+				BetaRogue:         15,
+				ConcurrentRepolls: 2,
+			},
+			Parents:   5,
+			BatchSize: 10,
+		},
+		// Samples more validators and requires more rounds to finalize
+		HighSecurityProfile: {
+			Parameters: snowball.Parameters{
+				K:                 20,
+				Alpha:             15,
+				BetaVirtuous:      30,
+				BetaRogue:         40,
+				ConcurrentRepolls: 1,
+			},
+			Parents:   5,
+			BatchSize: 30,
+		},
+	}
+)
+
+// ProfileParameters returns the parameters registered under [name]. The
+// returned parameters don't have a metrics registerer or namespace set.
+func ProfileParameters(name string) (Parameters, error) {
+	profilesLock.RLock()
+	defer profilesLock.RUnlock()
+
+	p, exists := profiles[name]
+	if !exists {
+		return Parameters{}, fmt.Errorf("no parameter profile named %s", name)
+	}
+	return p, nil
+}
+
+// RegisterProfile makes [p] available under [name]. The parameters must be
+// valid, and [name] must not already be registered.
+func RegisterProfile(name string, p Parameters) error {
+	if err := p.Valid(); err != nil {
+		return fmt.Errorf("parameter profile %s is invalid due to %w", name, err)
+	}
+
+	profilesLock.Lock()
+	defer profilesLock.Unlock()
+
+	if _, exists := profiles[name]; exists {
+		return fmt.Errorf("a parameter profile named %s is already registered", name)
+	}
+	profiles[name] = p
+	return nil
+}
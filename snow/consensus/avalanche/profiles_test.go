@@ -0,0 +1,74 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+)
+
+func TestProfileParametersBuiltin(t *testing.T) {
+	for _, name := range []string{DefaultProfile, LowLatencyProfile, HighSecurityProfile} {
+		p, err := ProfileParameters(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Valid(); err != nil {
+			t.Fatalf("Profile %s should be valid: %s", name, err)
+		}
+	}
+
+	p, err := ProfileParameters(DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	} else if p.K != 5 || p.Alpha != 4 || p.BetaVirtuous != 20 || p.BetaRogue != 30 {
+		t.Fatalf("Wrong default profile: %+v", p)
+	}
+
+	if _, err := ProfileParameters("unknown"); err == nil {
+		t.Fatalf("Should have errored on an unknown profile")
+	}
+}
+
+func TestRegisterProfile(t *testing.T) {
+	name := "test-register-profile"
+	p := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 3,
+			Alpha:             2,
+			BetaVirtuous:      5,
+			BetaRogue:         6,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+
+	if err := RegisterProfile(name, p); err != nil {
+		t.Fatal(err)
+	}
+
+	registered, err := ProfileParameters(name)
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(registered, p) {
+		t.Fatalf("Wrong profile. Expected %+v got %+v", p, registered)
+	}
+
+	if err := RegisterProfile(name, p); err == nil {
+		t.Fatalf("Should have errored on registering a profile twice")
+	}
+	if err := RegisterProfile(DefaultProfile, p); err == nil {
+		t.Fatalf("Should have errored on overwriting a built-in profile")
+	}
+
+	p.BatchSize = 0
+	if err := RegisterProfile("test-invalid-profile", p); err == nil {
+		t.Fatalf("Should have errored on registering invalid parameters")
+	} else if _, err := ProfileParameters("test-invalid-profile"); err == nil {
+		t.Fatalf("Invalid profile shouldn't have been registered")
+	}
+}
@@ -0,0 +1,175 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/gecko/ids"
+)
+
+const (
+	// SnapshotVersion is the version of the binary snapshot format produced by
+	// MarshalBinary
+	SnapshotVersion uint8 = 1
+
+	idLen = 32
+)
+
+var (
+	snapshotMagic = []byte("AVSS")
+
+	errBadSnapshotMagic   = errors.New("snapshot has an unexpected magic prefix")
+	errTruncatedSnapshot  = errors.New("snapshot is truncated")
+	errTrailingSnapshot   = errors.New("snapshot has trailing bytes")
+	errSnapshotCountRange = errors.New("snapshot count exceeds the remaining bytes")
+)
+
+// VertexSnapshot describes a single live vertex of the DAG
+type VertexSnapshot struct {
+	ID        ids.ID
+	ParentIDs []ids.ID
+	TxIDs     []ids.ID
+}
+
+// Snapshot describes the live vertices of the DAG, sorted by vertex ID
+type Snapshot struct {
+	// AcceptanceSequence is the sequence number of the most recently accepted
+	// vertex
+	AcceptanceSequence uint64
+	Vertices           []VertexSnapshot
+}
+
+// Snapshot returns a description of the current live DAG
+func (ta *Topological) Snapshot() Snapshot {
+	vts := ta.Processing()
+	snapshot := Snapshot{
+		AcceptanceSequence: ta.acceptanceSequence,
+		Vertices:           make([]VertexSnapshot, len(vts)),
+	}
+	for i, vtx := range vts {
+		vtxSnapshot := VertexSnapshot{ID: vtx.ID()}
+		for _, parent := range ta.parents(vtx) {
+			vtxSnapshot.ParentIDs = append(vtxSnapshot.ParentIDs, parent.ID())
+		}
+		for _, tx := range vtx.Txs() {
+			vtxSnapshot.TxIDs = append(vtxSnapshot.TxIDs, tx.ID())
+		}
+		snapshot.Vertices[i] = vtxSnapshot
+	}
+	return snapshot
+}
+
+// MarshalBinary encodes the snapshot as the magic prefix, followed by the
+// format version, followed by the varint acceptance sequence number, followed
+// by the varint prefixed list of vertices. Each vertex
+// is encoded as its ID followed by the varint prefixed lists of its parent IDs
+// and transaction IDs.
+func (s Snapshot) MarshalBinary() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.Write(snapshotMagic)
+	buf.WriteByte(SnapshotVersion)
+	writeUvarint(&buf, s.AcceptanceSequence)
+
+	writeUvarint(&buf, uint64(len(s.Vertices)))
+	for _, vtx := range s.Vertices {
+		buf.Write(vtx.ID.Bytes())
+		writeIDs(&buf, vtx.ParentIDs)
+		writeIDs(&buf, vtx.TxIDs)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary is the inverse of MarshalBinary. Snapshots encoded with an
+// unknown version are rejected.
+func (s *Snapshot) UnmarshalBinary(b []byte) error {
+	if len(b) < len(snapshotMagic)+1 {
+		return errTruncatedSnapshot
+	}
+	if !bytes.Equal(b[:len(snapshotMagic)], snapshotMagic) {
+		return errBadSnapshotMagic
+	}
+	if version := b[len(snapshotMagic)]; version != SnapshotVersion {
+		return fmt.Errorf("snapshot version %d is not supported, expected %d", version, SnapshotVersion)
+	}
+	r := bytes.NewReader(b[len(snapshotMagic)+1:])
+
+	acceptanceSequence, err := binary.ReadUvarint(r)
+	if err != nil {
+		return errTruncatedSnapshot
+	}
+	numVts, err := readCount(r, idLen)
+	if err != nil {
+		return err
+	}
+	vts := make([]VertexSnapshot, numVts)
+	for i := range vts {
+		if vts[i].ID, err = readID(r); err != nil {
+			return err
+		}
+		if vts[i].ParentIDs, err = readIDs(r); err != nil {
+			return err
+		}
+		if vts[i].TxIDs, err = readIDs(r); err != nil {
+			return err
+		}
+	}
+	if r.Len() != 0 {
+		return errTrailingSnapshot
+	}
+
+	s.AcceptanceSequence = acceptanceSequence
+	s.Vertices = vts
+	return nil
+}
+
+func writeUvarint(buf *bytes.Buffer, n uint64) {
+	varint := [binary.MaxVarintLen64]byte{}
+	buf.Write(varint[:binary.PutUvarint(varint[:], n)])
+}
+
+func writeIDs(buf *bytes.Buffer, idList []ids.ID) {
+	writeUvarint(buf, uint64(len(idList)))
+	for _, id := range idList {
+		buf.Write(id.Bytes())
+	}
+}
+
+// readCount reads a varint count of elements that are each at least [minSize]
+// bytes long
+func readCount(r *bytes.Reader, minSize int) (int, error) {
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, errTruncatedSnapshot
+	}
+	if count > uint64(r.Len()/minSize) {
+		return 0, errSnapshotCountRange
+	}
+	return int(count), nil
+}
+
+func readID(r *bytes.Reader) (ids.ID, error) {
+	id := [idLen]byte{}
+	if n, _ := r.Read(id[:]); n != idLen {
+		return ids.ID{}, errTruncatedSnapshot
+	}
+	return ids.NewID(id), nil
+}
+
+func readIDs(r *bytes.Reader) ([]ids.ID, error) {
+	count, err := readCount(r, idLen)
+	if err != nil || count == 0 {
+		return nil, err
+	}
+	idList := make([]ids.ID, count)
+	for i := range idList {
+		if idList[i], err = readID(r); err != nil {
+			return nil, err
+		}
+	}
+	return idList, nil
+}
@@ -0,0 +1,103 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+func TestSnapshotBinaryRoundTrip(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID(), GenerateID()}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxos[0])
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[1])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: []Vertex{vtx0},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0, tx1},
+		height:       2,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	snapshot := ta.Snapshot()
+	if len(snapshot.Vertices) != 2 {
+		t.Fatalf("Snapshot has %d vertices, expected %d", len(snapshot.Vertices), 2)
+	}
+
+	b, err := snapshot.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := Snapshot{}
+	if err := parsed.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snapshot, parsed) {
+		t.Fatalf("Snapshot changed during round trip:\n%v\n%v", snapshot, parsed)
+	}
+}
+
+func TestSnapshotBinaryBadVersion(t *testing.T) {
+	b, err := Snapshot{}.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(snapshotMagic)] = SnapshotVersion + 1
+
+	if err := (&Snapshot{}).UnmarshalBinary(b); err == nil {
+		t.Fatalf("Should have rejected an unknown snapshot version")
+	}
+}
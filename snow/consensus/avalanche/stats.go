@@ -0,0 +1,158 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+)
+
+// latencyWindow is the number of most recent polls that the latency
+// percentiles of a MetricsSnapshot are calculated over
+const latencyWindow = 1024
+
+// MetricsSnapshot is a copy of the statistics of an instance at a point in
+// time. Unlike the Prometheus metrics, it is always collected, so tools that
+// embed the instance can report these statistics however they prefer.
+type MetricsSnapshot struct {
+	// Number of vertices that started processing
+	Issued uint64
+	// Number of processing vertices that were accepted
+	Accepted uint64
+	// Number of processing vertices that were rejected
+	Rejected uint64
+	// Number of processing vertices that were evicted without being decided
+	Removed uint64
+	// Number of votes that were for decided or unknown vertices
+	WastedVotes uint64
+	// Number of wasted votes that were for vertices known to have been decided
+	LateVotes uint64
+	// Number of vertices dropped because they differed from a processing
+	// vertex with the same ID
+	Equivocations uint64
+	// Number of polls that have been recorded
+	Polls uint64
+
+	// Number of currently processing vertices
+	Processing int
+	// Number of vertices without any descendents
+	Frontier int
+	// Number of vertices in the preferred frontier
+	Preferred int
+	// Number of vertices in the virtuous frontier
+	Virtuous int
+	// Number of transactions that are virtuous, but not preferred
+	Orphans int
+
+	// The median and 99th percentile latency of recording a poll, over the
+	// last latencyWindow polls
+	PollLatencyP50, PollLatencyP99 time.Duration
+}
+
+// MetricsSnapshot returns the current statistics of this instance
+func (ta *Topological) MetricsSnapshot() MetricsSnapshot {
+	s := &ta.stats
+	return MetricsSnapshot{
+		Issued:        s.issued,
+		Accepted:      s.accepted,
+		Rejected:      s.rejected,
+		Removed:       s.removed,
+		WastedVotes:   s.wastedVotes,
+		LateVotes:     s.lateVotes,
+		Equivocations: s.equivocations,
+		Polls:         ta.numPolls,
+
+		Processing: len(ta.nodes),
+		Frontier:   len(ta.frontier),
+		Preferred:  ta.preferred.Len(),
+		Virtuous:   ta.virtuous.Len(),
+		Orphans:    ta.orphans.Len(),
+
+		PollLatencyP50: s.latencyPercentile(50),
+		PollLatencyP99: s.latencyPercentile(99),
+	}
+}
+
+// stats is a MetricsSink that keeps the statistics reported by
+// MetricsSnapshot before forwarding each event to the next MetricsSink
+type stats struct {
+	next MetricsSink
+
+	issued, accepted, rejected, removed uint64
+	wastedVotes, lateVotes              uint64
+	equivocations                       uint64
+
+	// The latencies of the last latencyWindow polls. Once full, the oldest
+	// latency is at nextLatency.
+	latencies   []time.Duration
+	nextLatency int
+}
+
+func (s *stats) initialize(next MetricsSink) { *s = stats{next: next} }
+
+func (s *stats) Issued(vtxID ids.ID) {
+	s.issued++
+	s.next.Issued(vtxID)
+}
+
+func (s *stats) Accepted(vtxID ids.ID) {
+	s.accepted++
+	s.next.Accepted(vtxID)
+}
+
+func (s *stats) Rejected(vtxID ids.ID) {
+	s.rejected++
+	s.next.Rejected(vtxID)
+}
+
+func (s *stats) Removed(vtxID ids.ID) {
+	s.removed++
+	s.next.Removed(vtxID)
+}
+
+func (s *stats) WastedVotes(numVotes int) {
+	s.wastedVotes += uint64(numVotes)
+	s.next.WastedVotes(numVotes)
+}
+
+func (s *stats) LateVotes(numVotes int) {
+	s.lateVotes += uint64(numVotes)
+	s.next.LateVotes(numVotes)
+}
+
+func (s *stats) Equivocated(vtxID ids.ID) {
+	s.equivocations++
+	s.next.Equivocated(vtxID)
+}
+
+func (s *stats) Orphans(numOrphans int) { s.next.Orphans(numOrphans) }
+
+func (s *stats) ObservePollLatency(latency time.Duration) {
+	if len(s.latencies) < latencyWindow {
+		s.latencies = append(s.latencies, latency)
+	} else {
+		s.latencies[s.nextLatency] = latency
+		s.nextLatency = (s.nextLatency + 1) % latencyWindow
+	}
+	s.next.ObservePollLatency(latency)
+}
+
+// latencyPercentile returns the [percentile]th percentile of the recent poll
+// latencies using the nearest rank method, or 0 if no poll has been recorded
+func (s *stats) latencyPercentile(percentile int) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(s.latencies))
+	copy(sorted, s.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := (percentile*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
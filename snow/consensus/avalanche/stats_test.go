@@ -0,0 +1,181 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+func TestAvalancheMetricsSnapshot(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:           2,
+		BatchSize:         1,
+		DecisionRetention: time.Minute,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	if snapshot := ta.MetricsSnapshot(); snapshot != (MetricsSnapshot{Frontier: 2, Preferred: 2, Virtuous: 2}) {
+		t.Fatalf("Wrong initial snapshot %+v", snapshot)
+	}
+
+	utxo := GenerateID()
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxo)
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxo)
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(GenerateID())
+
+	vtx2 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	// A vertex with the same ID as vtx2 but a different tx is dropped
+	ta.Add(&Vtx{
+		dependencies: vts,
+		id:           vtx2.id,
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	})
+
+	snapshot := ta.MetricsSnapshot()
+	switch {
+	case snapshot.Issued != 3:
+		t.Fatalf("Expected %d issued vertices, got %d", 3, snapshot.Issued)
+	case snapshot.Equivocations != 1:
+		t.Fatalf("Expected %d equivocations, got %d", 1, snapshot.Equivocations)
+	case snapshot.Processing != 3:
+		t.Fatalf("Expected %d processing vertices, got %d", 3, snapshot.Processing)
+	case snapshot.Frontier != 3:
+		t.Fatalf("Expected a frontier of %d vertices, got %d", 3, snapshot.Frontier)
+	case snapshot.Preferred != 2:
+		t.Fatalf("Expected %d preferred vertices, got %d", 2, snapshot.Preferred)
+	case snapshot.Accepted != 0:
+		t.Fatalf("Nothing should have been accepted yet, got %+v", snapshot)
+	}
+
+	// The clock is frozen so that the poll latencies are deterministic
+	ta.clock.Set(time.Unix(1, 0))
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id, vtx2.id, GenerateID())
+	ta.RecordPoll(votes)
+
+	votes = ids.UniqueBag{}
+	votes.Add(0, vtx0.id, vtx2.id)
+	ta.RecordPoll(votes)
+
+	if !ta.Finalized() {
+		t.Fatalf("An avalanche instance should have finalized")
+	}
+
+	snapshot = ta.MetricsSnapshot()
+	switch {
+	case snapshot.Issued != 3:
+		t.Fatalf("Expected %d issued vertices, got %d", 3, snapshot.Issued)
+	case snapshot.Accepted != 2:
+		t.Fatalf("Expected %d accepted vertices, got %d", 2, snapshot.Accepted)
+	case snapshot.Rejected != 1:
+		t.Fatalf("Expected %d rejected vertices, got %d", 1, snapshot.Rejected)
+	case snapshot.Removed != 0:
+		t.Fatalf("Expected %d removed vertices, got %d", 0, snapshot.Removed)
+	case snapshot.WastedVotes != 2:
+		t.Fatalf("Expected %d wasted votes, got %d", 2, snapshot.WastedVotes)
+	case snapshot.LateVotes != 1:
+		t.Fatalf("Expected %d late votes, got %d", 1, snapshot.LateVotes)
+	case snapshot.Polls != 2:
+		t.Fatalf("Expected %d polls, got %d", 2, snapshot.Polls)
+	case snapshot.Processing != 0:
+		t.Fatalf("Expected %d processing vertices, got %d", 0, snapshot.Processing)
+	case snapshot.PollLatencyP50 != 0 || snapshot.PollLatencyP99 != 0:
+		t.Fatalf("Expected no poll latency, got %s and %s", snapshot.PollLatencyP50, snapshot.PollLatencyP99)
+	}
+}
+
+func TestStatsPollLatency(t *testing.T) {
+	s := stats{}
+	s.initialize(NoMetrics{})
+
+	if latency := s.latencyPercentile(50); latency != 0 {
+		t.Fatalf("Expected no latency before any polls, got %s", latency)
+	}
+
+	// Only the last latencyWindow polls are kept, so the first polls, which
+	// are slower than the rest, are forgotten
+	for i := 0; i < latencyWindow; i++ {
+		s.ObservePollLatency(time.Hour)
+	}
+	for i := 1; i <= latencyWindow; i++ {
+		s.ObservePollLatency(time.Duration(i) * time.Millisecond)
+	}
+
+	if latency, expected := s.latencyPercentile(50), latencyWindow/2*time.Millisecond; latency != expected {
+		t.Fatalf("Expected a median poll latency of %s, got %s", expected, latency)
+	} else if latency, expected := s.latencyPercentile(99), time.Duration((99*latencyWindow+99)/100)*time.Millisecond; latency != expected {
+		t.Fatalf("Expected a 99th percentile poll latency of %s, got %s", expected, latency)
+	}
+}
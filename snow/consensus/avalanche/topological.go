@@ -4,10 +4,61 @@
 package avalanche
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow"
 	"github.com/ava-labs/gecko/snow/choices"
 	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+	"github.com/ava-labs/gecko/utils/hashing"
+	"github.com/ava-labs/gecko/utils/timer"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+// Reasons reported to OnReject for why a vertex was rejected
+const (
+	// RejectReasonParentRejected is reported when a parent of the vertex was
+	// rejected
+	RejectReasonParentRejected = "parent rejected"
+	// RejectReasonRejectedTx is reported when the vertex contained a
+	// transaction that was already rejected when the vertex was issued, or
+	// that was removed with RemoveTx
+	RejectReasonRejectedTx = "contains rejected tx"
+	// RejectReasonConflict is reported when a transaction in the vertex was
+	// rejected due to a conflicting transaction being accepted
+	RejectReasonConflict = "conflict"
+)
+
+const (
+	// pollReplayWindow is the number of recent poll IDs that are remembered
+	// to ignore repeated deliveries of the same poll
+	pollReplayWindow = 128
+
+	// acceptRateWindow is the period that AcceptRate is averaged over
+	acceptRateWindow = 10 * time.Second
+)
+
+var (
+	// ErrLiveSetFull is returned when adding a vertex would exceed the
+	// maximum number of live vertices
+	ErrLiveSetFull = errors.New("live vertex set is full")
+
+	// ErrVertexEquivocation is returned when a vertex is added with the same ID
+	// as a live vertex, but with different parents or transactions
+	ErrVertexEquivocation = errors.New("vertex differs from the live vertex with the same ID")
+
+	errNilVertex        = errors.New("attempting to insert nil vertex")
+	errNilTx            = errors.New("vertex contains a nil transaction")
+	errInDegreeOverflow = errors.New("vertex in-degree exceeded the maximum")
+
+	// maxInDegree bounds the number of transitive references a vertex may
+	// receive during a single poll. It fits in an int on 32-bit builds.
+	maxInDegree = math.MaxInt32
 )
 
 // TopologicalFactory implements Factory by returning a topological struct
@@ -16,17 +67,19 @@ type TopologicalFactory struct{}
 // New implements Factory
 func (TopologicalFactory) New() Consensus { return &Topological{} }
 
-// TODO: Implement pruning of decisions.
-// To perfectly preserve the protocol, this implementation will need to store
-// the hashes of all accepted decisions. It is possible to add a heuristic that
-// removes sufficiently old decisions. However, that will need to be analyzed to
-// ensure safety. It is doable when adding in a weak synchrony assumption.
+// To perfectly preserve the protocol, this implementation would need to store
+// the hashes of all accepted decisions. Instead, decisions are remembered for
+// DecisionRetention, and at most MaxHistoryVertices accepted decisions are
+// remembered, which is safe under a weak synchrony assumption.
 
 // Topological performs the avalanche algorithm by utilizing a topological sort
 // of the voting results. Assumes that vertices are inserted in topological
 // order.
 type Topological struct {
-	metrics
+	// Receives the metric events of this instance, which are recorded by stats
+	// before being forwarded to the configured MetricsSink
+	metrics MetricsSink
+	stats   stats
 
 	// Context used for logging
 	ctx *snow.Context
@@ -35,8 +88,80 @@ type Topological struct {
 
 	// Maps vtxID -> vtx
 	nodes map[[32]byte]Vertex
+	// Maps vtxID -> IDs of the live vertices that have it as a parent
+	children map[[32]byte]ids.Set
+	// Maps txID -> IDs of the live vertices that contain it
+	txVertices map[[32]byte]ids.Set
+	// IDs of the live txs that aren't accepted or virtuous. The conflictless
+	// fast path can only be used when this is empty.
+	rogueLiveTxs ids.Set
+	// IDs of the live vertices that contained rejected txs when issued
+	issuedRejected ids.Set
+	// Sequence number of the most recently accepted vertex
+	acceptanceSequence uint64
+	// The last AcceptedLogSize accepted vertices
+	acceptedLog acceptedLog
 	// Tracks the conflict relations
 	cg snowstorm.Consensus
+	// The preferred and virtuous txs of the conflict graph, memoized until the
+	// conflict graph is next modified
+	cgPreferences, cgVirtuous ids.Set
+	cgSetsCached              bool
+	// Maps txID -> number of polls the virtuous tx has remained processing for
+	virtuousTxPolls map[[32]byte]int
+	// IDs of the txs that were accepted during the most recent poll
+	lastAcceptedTxs []ids.ID
+	// IDs of the vertices that were accepted during the most recent Add or
+	// RecordPoll
+	recentlyAccepted []ids.ID
+	// Used to timestamp accepts, can be faked for testing
+	clock timer.Clock
+	// Times of the accepts within the last acceptRateWindow, in order
+	acceptTimes []time.Time
+	// onLivenessTimeout, if non-nil, is called when no vertex has been decided
+	// for livenessTimeout, measured from livenessStart
+	onLivenessTimeout func()
+	livenessTimeout   time.Duration
+	livenessStart     time.Time
+	// Progress reported by HealthCheck
+	health health
+	// IDs of the most recently recorded polls, used to ignore repeated polls
+	recentPollIDs   map[uint32]bool
+	recentPollOrder []uint32
+	// Number of polls that have been recorded
+	numPolls uint64
+	// Maps txID -> the votes the tx received in recent polls
+	txVoteHistory map[[32]byte]*voteRing
+	// IDs of the txs whose histories were kept after they stopped processing,
+	// oldest first
+	decidedVoteHistories [][32]byte
+	// The fractional confidence contribution carried over between weighted polls
+	voteCredit float64
+	// Changes to the preferred frontier during the most recent poll
+	preferenceAdded, preferenceRemoved ids.Set
+	// The validators that voted for conflicting vertices in each of the last
+	// EquivocationWindow polls
+	recentEquivocators []ids.ShortSet
+	// The validators that were polled, and those that responded, in each of
+	// the last ParticipationWindow polls
+	recentPolled, recentResponders []ids.ShortSet
+	// Recently decided vertices, retained for DecisionRetention
+	decisions decisions
+	// IDs of the accepted vertices whose decisions are remembered, oldest
+	// first, if MaxHistoryVertices is set
+	acceptedHistory []ids.ID
+	// pruner, if non-nil, periodically prunes the decided vertices
+	pruner *timer.Repeater
+	// Every vertex accepted by this instance, if AcceptedFilterSize is set
+	acceptedFilter bloomFilter
+	// True while the frontiers are being recomputed to verify them, and the
+	// vertices the recomputation would have decided
+	verifying           bool
+	unexpectedDecisions ids.Set
+	// The transactions that were rogue at the start of the current poll, and
+	// whether acceptances of vertices containing them are being deferred
+	rogueTxs          ids.Set
+	deferRogueAccepts bool
 
 	// preferred is the frontier of vtxIDs that are strongly preferred
 	// virtuous is the frontier of vtxIDs that are strongly virtuous
@@ -55,26 +180,101 @@ type kahnNode struct {
 }
 
 // Initialize implements the Avalanche interface
-func (ta *Topological) Initialize(ctx *snow.Context, params Parameters, frontier []Vertex) {
-	ctx.Log.AssertDeferredNoError(params.Valid)
+func (ta *Topological) Initialize(ctx *snow.Context, params Parameters, frontier []Vertex) error {
+	i := 0
+	return ta.InitializeStream(ctx, params, func() (Vertex, bool) {
+		if i >= len(frontier) {
+			return nil, false
+		}
+		vtx := frontier[i]
+		i++
+		return vtx, true
+	})
+}
+
+// InitializeWithAccepted is equivalent to Initialize, except that the vertices
+// with IDs in [accepted] are recorded as having been accepted, as though they
+// had been accepted by this instance. This allows an instance bootstrapped
+// from a checkpoint to report the checkpointed vertices from ProbablyAccepted
+// and VtxStatus without running consensus on them.
+func (ta *Topological) InitializeWithAccepted(ctx *snow.Context, params Parameters, frontier []Vertex, accepted []ids.ID) error {
+	err := ta.Initialize(ctx, params, frontier)
+	for _, vtxID := range accepted {
+		ta.decided(vtxID, choices.Accepted)
+		ta.acceptedFilter.add(vtxID)
+	}
+	return err
+}
 
+// InitializeStream is equivalent to Initialize, except that the accepted
+// frontier is pulled from [next] until it reports that it is exhausted. This
+// allows the frontier to be loaded without materializing it up front.
+func (ta *Topological) InitializeStream(ctx *snow.Context, params Parameters, next func() (Vertex, bool)) error {
 	ta.ctx = ctx
 	ta.params = params
 
-	if err := ta.metrics.Initialize(ctx.Log, params.Namespace, params.Metrics); err != nil {
-		ta.ctx.Log.Error("%s", err)
+	ctx.Log.Info("Initializing avalanche consensus with %s", params)
+
+	errs := wrappers.Errs{}
+	errs.Add(params.Valid())
+
+	sink := params.MetricsSink
+	if sink == nil {
+		m := &metrics{clock: &ta.clock}
+		errs.Add(m.Initialize(ctx.Log, params.Namespace, params.Metrics))
+		sink = m
 	}
+	ta.stats.initialize(sink)
+	ta.metrics = &ta.stats
 
 	ta.nodes = make(map[[32]byte]Vertex)
-
+	ta.children = make(map[[32]byte]ids.Set)
+	ta.txVertices = make(map[[32]byte]ids.Set)
+	ta.rogueLiveTxs = ids.Set{}
+
+	cgParams := params.Parameters
+	if cgParams.Metrics == nil {
+		// The conflict graph always reports to Prometheus, so its metrics are
+		// dropped when no registerer was provided.
+		cgParams.Metrics = prometheus.NewRegistry()
+	}
 	ta.cg = &snowstorm.Directed{}
-	ta.cg.Initialize(ctx, params.Parameters)
+	ta.cg.Initialize(ctx, cgParams)
+	ta.cgSetsCached = false
+	ta.virtuousTxPolls = make(map[[32]byte]int)
+	ta.decisions.initialize(&ta.clock)
+	ta.acceptedHistory = nil
+	ta.acceptedFilter.initialize(params.AcceptedFilterSize)
+	ta.recentEquivocators = nil
+	ta.recentPolled, ta.recentResponders = nil, nil
+	ta.recentPollIDs = make(map[uint32]bool)
+	ta.acceptTimes = nil
+	ta.health = health{lastAccept: ta.clock.Time()}
+	ta.recentPollOrder = nil
+	ta.voteCredit = 0
+	ta.numPolls = 0
+	ta.acceptanceSequence = 0
+	ta.acceptedLog.initialize(params.AcceptedLogSize, 0)
+	ta.txVoteHistory = make(map[[32]byte]*voteRing)
+	ta.decidedVoteHistories = nil
 
 	ta.frontier = make(map[[32]byte]Vertex)
-	for _, vtx := range frontier {
+	event := Event{
+		Type:       InitializeEvent,
+		Context:    ctx,
+		Parameters: params,
+	}
+	for vtx, ok := next(); ok; vtx, ok = next() {
 		ta.frontier[vtx.ID().Key()] = vtx
+		if params.EventRecorder != nil {
+			event.VertexBytes = append(event.VertexBytes, vtx.Bytes())
+		}
+	}
+	if params.EventRecorder != nil {
+		params.EventRecorder.Record(event)
 	}
 	ta.updateFrontiers()
+	return errs.Err
 }
 
 // Parameters implements the Avalanche interface
@@ -83,31 +283,161 @@ func (ta *Topological) Parameters() Parameters { return ta.params }
 // IsVirtuous implements the Avalanche interface
 func (ta *Topological) IsVirtuous(tx snowstorm.Tx) bool { return ta.cg.IsVirtuous(tx) }
 
-// Add implements the Avalanche interface
+// Add implements the Avalanche interface. If the live set is full, or the
+// vertex is too large, the vertex is dropped.
 func (ta *Topological) Add(vtx Vertex) {
+	if err := ta.AddChecked(vtx); err != nil {
+		ta.ctx.Log.Warn("Dropping vertex %s due to %s", vtx.ID(), err)
+	}
+}
+
+// AddChecked adds the vertex in the same manner as Add. However, if adding the
+// vertex would grow the live set beyond MaxLiveVertices, ErrLiveSetFull is
+// returned and the vertex isn't added. Similarly, an error is returned if the
+// vertex is larger than MaxVtxSize, if the vertex would extend a chain of
+// processing vertices beyond MaxProcessingDepth, or if the vertex contains a
+// nil or duplicated transaction, as those would be double counted when
+// collecting votes. Vertices that are decided or already live are never rejected, unless
+// a live vertex with the same ID has different parents or transactions, in
+// which case ErrVertexEquivocation is returned.
+func (ta *Topological) AddChecked(vtx Vertex) error {
+	ta.recentlyAccepted = nil
 	ta.ctx.Log.AssertTrue(vtx != nil, "Attempting to insert nil vertex")
+	ta.recordAdd(vtx)
 
 	vtxID := vtx.ID()
 	key := vtxID.Key()
 	if vtx.Status().Decided() {
-		return // Already decided this vertex
-	} else if _, exists := ta.nodes[key]; exists {
-		return // Already inserted this vertex
+		return nil // Already decided this vertex
+	} else if err := verifyTxs(vtx); err != nil {
+		return err
+	} else if existing, exists := ta.nodes[key]; exists {
+		if !ta.sameVertex(existing, vtx) {
+			// A byzantine node issued different vertices with the same ID
+			ta.metrics.Equivocated(vtxID)
+			return ErrVertexEquivocation
+		}
+		return nil // Already inserted this vertex
+	} else if max := ta.params.MaxLiveVertices; max > 0 && len(ta.nodes) >= max {
+		return ErrLiveSetFull
+	} else if max := ta.params.MaxVtxSize; max > 0 && len(vtx.Bytes()) > max {
+		return fmt.Errorf("vertex is %d bytes, which exceeds the maximum of %d bytes", len(vtx.Bytes()), max)
+	} else if max := ta.params.MaxProcessingDepth; max > 0 && ta.processingDepth(vtx, max) > max {
+		return fmt.Errorf("vertex has more than %d processing vertices in a chain of its ancestry", max-1)
 	}
 
 	ta.ctx.ConsensusDispatcher.Issue(ta.ctx.ChainID, vtxID, vtx.Bytes())
 
 	for _, tx := range vtx.Txs() {
-		if !tx.Status().Decided() {
+		switch tx.Status() {
+		case choices.Rejected:
+			ta.issuedRejected.Add(vtxID)
+		case choices.Accepted:
+		default:
 			// Add the consumers to the conflict graph.
 			ta.cg.Add(tx)
+			ta.cgSetsCached = false
 		}
+
+		txKey := tx.ID().Key()
+		vtxIDs := ta.txVertices[txKey]
+		vtxIDs.Add(vtxID)
+		ta.txVertices[txKey] = vtxIDs
 	}
+	ta.updateRogueLiveTxs(vtx)
 
+	if len(ta.nodes) == 0 {
+		ta.resetLiveness() // Consensus can't stall with nothing to decide
+	}
 	ta.nodes[key] = vtx // Add this vertex to the set of nodes
+	ta.processingIncreased()
+	for _, parent := range ta.parents(vtx) {
+		parentKey := parent.ID().Key()
+		children := ta.children[parentKey]
+		children.Add(vtxID)
+		ta.children[parentKey] = children
+	}
 	ta.metrics.Issued(vtxID)
 
 	ta.update(vtx) // Update the vertex and it's ancestry
+	return nil
+}
+
+// processingDepth returns the number of vertices in the longest chain of
+// processing vertices that would end at [vtx], counting [vtx]. The chains are
+// walked one layer of parents at a time, so once the depth exceeds [max], max+1
+// is returned without walking the rest of the ancestry.
+func (ta *Topological) processingDepth(vtx Vertex, max int) int {
+	depth := 0
+	layer := map[[32]byte]Vertex{vtx.ID().Key(): vtx}
+	for len(layer) > 0 && depth <= max {
+		depth++
+		parents := make(map[[32]byte]Vertex)
+		for _, vtx := range layer {
+			for _, parent := range ta.parents(vtx) {
+				parentKey := parent.ID().Key()
+				if _, live := ta.nodes[parentKey]; live {
+					parents[parentKey] = parent
+				}
+			}
+		}
+		layer = parents
+	}
+	return depth
+}
+
+// verifyTxs returns an error if [vtx] contains a nil transaction or contains
+// the same transaction more than once
+func verifyTxs(vtx Vertex) error {
+	txIDs := ids.Set{}
+	for _, tx := range vtx.Txs() {
+		if tx == nil {
+			return errNilTx
+		}
+		txID := tx.ID()
+		if txIDs.Contains(txID) {
+			return fmt.Errorf("vertex contains tx %s multiple times", txID)
+		}
+		txIDs.Add(txID)
+	}
+	return nil
+}
+
+// AddWithResult adds the vertex in the same manner as Add, and returns the IDs
+// of the vertices that were accepted as a direct result of adding it.
+func (ta *Topological) AddWithResult(vtx Vertex) []ids.ID {
+	ta.Add(vtx)
+	return ta.recentlyAccepted
+}
+
+// SafeAdd adds the vertex in the same manner as AddChecked. However, if the
+// vertex panics while being inspected, the panic is recovered and returned as
+// an error. This is intended for fuzzing with malformed vertices; after an error
+// is returned the instance may be left in an inconsistent state.
+func (ta *Topological) SafeAdd(vtx Vertex) (err error) {
+	if vtx == nil {
+		return errNilVertex
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("adding vertex failed due to: %v", r)
+		}
+	}()
+
+	return ta.AddChecked(vtx)
+}
+
+// AcceptanceSequence returns the sequence number of the most recently accepted
+// vertex, or 0 if no vertex has been accepted.
+func (ta *Topological) AcceptanceSequence() uint64 { return ta.acceptanceSequence }
+
+// ResumeAcceptanceSequence continues the acceptance sequence from [sequence],
+// such as the AcceptanceSequence of a Snapshot taken before a restart. It
+// should be called after Initialize and before any vertices are added.
+func (ta *Topological) ResumeAcceptanceSequence(sequence uint64) {
+	ta.acceptanceSequence = sequence
+	ta.acceptedLog.initialize(ta.params.AcceptedLogSize, sequence)
 }
 
 // VertexIssued implements the Avalanche interface
@@ -119,9 +449,221 @@ func (ta *Topological) VertexIssued(vtx Vertex) bool {
 	return ok
 }
 
+// RemoveVtx forcibly evicts the live vertex [vtxID] without deciding it. Because
+// the descendants of an evicted vertex can no longer be decided, they are
+// evicted as well. Transactions that are no longer in any live vertex can't
+// receive any more votes, so they are rejected and removed from the conflict
+// graph. Returns true if the vertex was live.
+func (ta *Topological) RemoveVtx(vtxID ids.ID) bool {
+	vtx, live := ta.nodes[vtxID.Key()]
+	if !live {
+		return false
+	}
+
+	evictedTxs := []snowstorm.Tx(nil)
+	evicting := []Vertex{vtx}
+	for len(evicting) > 0 {
+		newLen := len(evicting) - 1
+		vtx := evicting[newLen]
+		evicting = evicting[:newLen]
+
+		vtxID := vtx.ID()
+		key := vtxID.Key()
+		if _, live := ta.nodes[key]; !live {
+			continue // Already evicted through another descendant path
+		}
+
+		for _, childID := range ta.children[key].List() {
+			evicting = append(evicting, ta.nodes[childID.Key()])
+		}
+
+		ta.removeNode(vtx)
+		ta.metrics.Removed(vtxID)
+		delete(ta.frontier, key)
+		ta.restoreParents(vtx)
+		evictedTxs = append(evictedTxs, vtx.Txs()...)
+	}
+
+	for _, tx := range evictedTxs {
+		txID := tx.ID()
+		if _, live := ta.txVertices[txID.Key()]; live || tx.Status() != choices.Processing {
+			continue
+		}
+		ta.ctx.Log.AssertNoError(ta.cg.Remove(txID))
+	}
+	ta.cgSetsCached = false
+
+	ta.updateFrontiers()
+	return true
+}
+
 // TxIssued implements the Avalanche interface
 func (ta *Topological) TxIssued(tx snowstorm.Tx) bool { return ta.cg.Issued(tx) }
 
+// TxInLiveVtx returns true if the tx with ID [txID] is contained in a vertex
+// that has been issued but not yet decided.
+func (ta *Topological) TxInLiveVtx(txID ids.ID) bool {
+	_, ok := ta.txVertices[txID.Key()]
+	return ok
+}
+
+// VtxFinalized returns true if the vertex with ID [vtxID] has been accepted or
+// rejected and is no longer live. Decided vertices are only known for
+// DecisionRetention after they were decided, or while they remain in the
+// accepted frontier.
+func (ta *Topological) VtxFinalized(vtxID ids.ID) bool {
+	return ta.VtxStatus(vtxID).Decided()
+}
+
+// VtxStatus returns Processing if the vertex with ID [vtxID] is live, and the
+// status it was decided with if it was decided within DecisionRetention or
+// remains in the accepted frontier. Otherwise, Unknown is returned.
+func (ta *Topological) VtxStatus(vtxID ids.ID) choices.Status {
+	vtxKey := vtxID.Key()
+	if _, live := ta.nodes[vtxKey]; live {
+		return choices.Processing
+	}
+	if vtx, ok := ta.frontier[vtxKey]; ok {
+		if status := vtx.Status(); status.Decided() {
+			return status
+		}
+	}
+	if status, ok := ta.decisions.status(vtxID); ok {
+		return status
+	}
+	return choices.Unknown
+}
+
+// ProbablyAccepted returns true if the vertex with ID [vtxID] may have been
+// accepted by this instance, even if its decision has since been pruned. There
+// are no false negatives, but a vertex that was never accepted is reported as
+// accepted with a probability that grows with the number of accepted vertices
+// relative to AcceptedFilterSize. If AcceptedFilterSize is zero, false is
+// always returned.
+func (ta *Topological) ProbablyAccepted(vtxID ids.ID) bool {
+	return ta.acceptedFilter.contains(vtxID)
+}
+
+// PreferredChild returns the strongly preferred live child of the vertex with
+// ID [vtxID]. If multiple children are preferred, the one with the lowest ID is
+// returned. If no child is preferred, false is returned.
+func (ta *Topological) PreferredChild(vtxID ids.ID) (ids.ID, bool) {
+	preferredChildren := []ids.ID(nil)
+	for _, childID := range ta.children[vtxID.Key()].List() {
+		if ta.preferenceCache[childID.Key()] {
+			preferredChildren = append(preferredChildren, childID)
+		}
+	}
+	if len(preferredChildren) == 0 {
+		return ids.ID{}, false
+	}
+	ids.SortIDs(preferredChildren)
+	return preferredChildren[0], true
+}
+
+// Ancestors returns the IDs of the live ancestors of the live vertex [vtxID]
+// that are at most [maxDepth] parent links away from it, in breadth first
+// order. Each ancestor is returned once, even if it is reachable by multiple
+// paths.
+func (ta *Topological) Ancestors(vtxID ids.ID, maxDepth int) []ids.ID {
+	vtx, live := ta.nodes[vtxID.Key()]
+	if !live {
+		return nil
+	}
+
+	ancestors := []ids.ID(nil)
+	seen := ids.Set{}
+	current := []Vertex{vtx}
+	for depth := 0; depth < maxDepth && len(current) > 0; depth++ {
+		next := []Vertex(nil)
+		for _, vtx := range current {
+			for _, parent := range ta.parents(vtx) {
+				parentID := parent.ID()
+				if _, live := ta.nodes[parentID.Key()]; !live || seen.Contains(parentID) {
+					continue
+				}
+				seen.Add(parentID)
+				ancestors = append(ancestors, parentID)
+				next = append(next, parent)
+			}
+		}
+		current = next
+	}
+	return ancestors
+}
+
+// WouldChangePreference estimates whether more votes for the vertex [vtxID]
+// could flip any preference. This is a heuristic: it returns true iff the
+// vertex is live and it, or one of its live ancestors, contains an undecided
+// transaction that conflicts with another transaction and isn't currently
+// preferred. It doesn't account for the number of votes that would be needed.
+func (ta *Topological) WouldChangePreference(vtxID ids.ID) bool {
+	vtx, live := ta.nodes[vtxID.Key()]
+	if !live {
+		return false
+	}
+
+	// Votes for a vertex are also applied to its ancestors
+	vts := []Vertex{vtx}
+	for _, ancestorID := range ta.Ancestors(vtxID, len(ta.nodes)) {
+		vts = append(vts, ta.nodes[ancestorID.Key()])
+	}
+
+	txPrefs, _ := ta.cgSets()
+	for _, vtx := range vts {
+		for _, tx := range vtx.Txs() {
+			if !tx.Status().Decided() && !ta.cg.IsVirtuous(tx) && !txPrefs.Contains(tx.ID()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ConflictGraph returns the conflict graph used to decide the transactions.
+//
+// This is an advanced and unstable API intended for diagnostics. The returned
+// graph must be treated as read-only; modifying it will corrupt this instance.
+func (ta *Topological) ConflictGraph() snowstorm.Consensus { return ta.cg }
+
+// Processing returns the processing vertices, sorted by ID. The returned slice
+// is newly allocated on every call.
+func (ta *Topological) Processing() []Vertex {
+	vtxIDs := make([]ids.ID, 0, len(ta.nodes))
+	for key := range ta.nodes {
+		vtxIDs = append(vtxIDs, ids.NewID(key))
+	}
+	ids.SortIDs(vtxIDs)
+
+	vts := make([]Vertex, len(vtxIDs))
+	for i, vtxID := range vtxIDs {
+		vts[i] = ta.nodes[vtxID.Key()]
+	}
+	return vts
+}
+
+// NumProcessing returns the number of processing vertices
+func (ta *Topological) NumProcessing() int { return len(ta.nodes) }
+
+// Conflicts returns the IDs of the processing transactions that conflict with
+// [tx]
+func (ta *Topological) Conflicts(tx snowstorm.Tx) ids.Set { return ta.cg.Conflicts(tx) }
+
+// RemoveTx rejects the processing transaction [txID] without it being voted
+// on, as it was invalidated outside of consensus. The vertices containing the
+// transaction, and their descendents, are rejected and the frontiers are
+// updated immediately. Returns an error if the transaction isn't processing.
+func (ta *Topological) RemoveTx(txID ids.ID) error {
+	ta.recentlyAccepted = nil
+	if err := ta.cg.Remove(txID); err != nil {
+		return err
+	}
+	ta.cgSetsCached = false
+	ta.issuedRejected.Union(ta.txVertices[txID.Key()])
+	ta.updateFrontiers()
+	return nil
+}
+
 // Orphans implements the Avalanche interface
 func (ta *Topological) Orphans() ids.Set { return ta.orphans }
 
@@ -131,17 +673,352 @@ func (ta *Topological) Virtuous() ids.Set { return ta.virtuous }
 // Preferences implements the Avalanche interface
 func (ta *Topological) Preferences() ids.Set { return ta.preferred }
 
+// BuildableSet returns the vertices of the preferred frontier whose
+// transactions don't conflict with each other, sorted by ID. Vertices are
+// considered in order and a vertex is skipped if any of its transactions
+// conflict with a transaction of a previously returned vertex, so the result
+// is maximal but not necessarily the largest possible set.
+func (ta *Topological) BuildableSet() []ids.ID {
+	preferred := ta.preferred.SortedList()
+
+	buildable := []ids.ID(nil)
+	txIDs := ids.Set{}
+	for _, vtxID := range preferred {
+		vtx, live := ta.nodes[vtxID.Key()]
+		if !live {
+			buildable = append(buildable, vtxID) // Accepted vertices can't conflict
+			continue
+		}
+
+		conflicts := false
+		for _, tx := range vtx.Txs() {
+			if txConflicts := ta.cg.Conflicts(tx); txConflicts.Overlaps(txIDs) {
+				conflicts = true
+				break
+			}
+		}
+		if conflicts {
+			continue
+		}
+
+		buildable = append(buildable, vtxID)
+		for _, tx := range vtx.Txs() {
+			txIDs.Add(tx.ID())
+		}
+	}
+	return buildable
+}
+
+// PreferencesWithConfidence returns the preferred vertices, keyed by their ID,
+// mapped to the minimum confidence of their undecided transactions. A
+// preferred vertex without undecided transactions has a confidence of 0.
+func (ta *Topological) PreferencesWithConfidence() map[[32]byte]int {
+	confidences := make(map[[32]byte]int, ta.preferred.Len())
+	for key := range ta.preferred {
+		vtx, ok := ta.nodes[key]
+		if !ok {
+			confidences[key] = 0 // Accepted vertices are trivially preferred
+			continue
+		}
+
+		confidence := -1
+		for _, tx := range vtx.Txs() {
+			if tx.Status().Decided() {
+				continue
+			}
+			if txConfidence := ta.cg.Confidence(tx); confidence == -1 || txConfidence < confidence {
+				confidence = txConfidence
+			}
+		}
+		if confidence == -1 {
+			confidence = 0
+		}
+		confidences[key] = confidence
+	}
+	return confidences
+}
+
+// PollResult summarizes the effects of recording a poll
+type PollResult struct {
+	// Number of processing vertices containing a transaction that received
+	// enough votes for its confidence to increase
+	GainedConfidence int
+	// Number of vertices accepted as a result of the poll
+	Accepted int
+	// Number of vertices rejected as a result of the poll
+	Rejected int
+}
+
 // RecordPoll implements the Avalanche interface
 func (ta *Topological) RecordPoll(responses ids.UniqueBag) {
-	// Set up the topological sort: O(|Live Set|)
-	kahns, leaves := ta.calculateInDegree(responses)
+	ta.recordPollEvent(Event{Type: RecordPollEvent}, responses)
+	ta.recordPoll(responses, nil, 1)
+}
+
+// RecordPollFrom implements the Avalanche interface
+func (ta *Topological) RecordPollFrom(responses ids.UniqueBag, voters []ids.ShortID) {
+	ta.recordPollEvent(Event{Type: RecordPollEvent, Voters: voters}, responses)
+	ta.recordPoll(responses, voters, 1)
+}
+
+// RecordPollResult records the poll in the same manner as RecordPoll, and
+// returns a summary of its effects. If the poll is dropped, the summary is
+// empty.
+func (ta *Topological) RecordPollResult(responses ids.UniqueBag) PollResult {
+	ta.recordPollEvent(Event{Type: RecordPollEvent}, responses)
+	return ta.recordPoll(responses, nil, 1)
+}
+
+// RecordWeightedPoll records the poll in the same manner as RecordPoll, except
+// that, if VoteDecay is set, older polls are discounted by a factor of
+// VoteDecay per poll relative to this one. Because confidence only counts
+// consecutive successful polls, this is applied by having each poll contribute
+// 1/VoteDecay to the confidence of the transactions it votes for, carrying any
+// fractional contribution over to the next weighted poll.
+//
+// Weighting polls reduces the number of successful polls needed to finalize a
+// transaction to roughly Beta*VoteDecay, which weakens the safety guarantees
+// provided by the Beta parameters in exchange for faster convergence. To bound
+// this, a poll never contributes more than BetaVirtuous-1 to the confidence of
+// a transaction, so no single poll can finalize a transaction by itself. Any
+// contribution over this cap is dropped rather than carried over.
+func (ta *Topological) RecordWeightedPoll(responses ids.UniqueBag) {
+	if ta.params.VoteDecay == 0 {
+		ta.RecordPoll(responses)
+		return
+	}
+
+	weight := ta.nextPollWeight()
+	ta.recordPollEvent(Event{Type: RecordWeightedPollEvent, Weight: weight}, responses)
+	ta.recordPoll(responses, nil, weight)
+}
+
+// nextPollWeight returns the weight of the next weighted poll, carrying any
+// fractional vote credit over to the poll after it
+func (ta *Topological) nextPollWeight() int {
+	ta.voteCredit += 1 / ta.params.VoteDecay
+	weight := int(ta.voteCredit)
+	ta.voteCredit -= float64(weight)
+	if maxWeight := ta.params.BetaVirtuous - 1; weight > maxWeight {
+		weight = maxWeight
+		if weight < 1 {
+			weight = 1
+		}
+	}
+	return weight
+}
+
+// recordPoll records [responses], applying the resulting transaction votes to
+// the conflict graph [weight] times. If known, voters[i] is the validator whose
+// response was recorded with index i in [responses].
+func (ta *Topological) recordPoll(responses ids.UniqueBag, voters []ids.ShortID, weight int) PollResult {
+	ta.lastAcceptedTxs = nil
+	ta.recentlyAccepted = nil
+	ta.preferenceAdded, ta.preferenceRemoved = nil, nil
+	ta.numPolls++
+	start := ta.clock.Time()
+	defer func() { ta.metrics.ObservePollLatency(ta.clock.Time().Sub(start)) }()
+	defer ta.checkLiveness()
+
+	pollSpan := ta.startSpan(nil, "RecordPoll")
+	setSpanAttribute(pollSpan, "responses", len(responses))
+	defer endSpan(pollSpan)
+
 	// Collect the votes for each transaction: O(|Live Set|)
-	votes := ta.pushVotes(kahns, leaves)
+	var (
+		votes *ids.Bag
+		err   error
+	)
+	if ta.conflictless() {
+		votes, err = ta.tracedConflictlessVotes(pollSpan, responses)
+	} else {
+		votes, err = ta.tracedKahnVotes(pollSpan, responses)
+	}
+	if err != nil {
+		ta.ctx.Log.Warn("Dropping poll due to %s", err)
+		return PollResult{}
+	}
+	defer ids.PutBag(votes)
+	ta.recordEquivocationVotes(responses, voters)
+	ta.recordParticipation(responses, voters)
+	result := PollResult{GainedConfidence: ta.numVotedVertices(votes.Threshold())}
+	numAccepted, numRejected := ta.stats.accepted, ta.stats.rejected
+	// Remember the processing transactions: O(|Live Set|)
+	processingTxs := ta.processingTxs()
+	if ta.params.VirtuousFirst {
+		ta.rogueTxs = ta.rogueTxIDs(processingTxs)
+	}
+	ta.recordVoteHistory(processingTxs, votes)
 	// Update the conflict graph: O(|Transactions|)
-	ta.ctx.Log.Verbo("Updating consumer confidences based on:\n%s", &votes)
-	ta.cg.RecordPoll(votes)
+	ta.ctx.Log.Verbo("Updating consumer confidences based on:\n%s", votes)
+	span := ta.startSpan(pollSpan, "cg.RecordPoll")
+	for i := 0; i < weight; i++ {
+		ta.cg.RecordPoll(*votes)
+	}
+	ta.cgSetsCached = false
+	setSpanAttribute(span, "weight", weight)
+	endSpan(span)
+	// Find the transactions that were accepted: O(|Transactions|)
+	for _, tx := range processingTxs {
+		if tx.Status() == choices.Accepted {
+			ta.lastAcceptedTxs = append(ta.lastAcceptedTxs, tx.ID())
+			ta.rogueLiveTxs.Remove(tx.ID())
+		}
+	}
+	// Age the virtuous transactions: O(|Transactions|)
+	ta.updateVirtuousTxPolls()
 	// Update the dag: O(|Live Set|)
-	ta.updateFrontiers()
+	previouslyPreferred := ta.preferred
+	if ta.params.VirtuousFirst {
+		// Accept the virtuous vertices before the rogue ones
+		ta.deferRogueAccepts = true
+		ta.tracedUpdateFrontiers(pollSpan)
+		ta.deferRogueAccepts = false
+	}
+	ta.tracedUpdateFrontiers(pollSpan)
+	if ta.params.VerifyFrontiers {
+		ta.ctx.Log.AssertNoError(ta.verifyFrontiers())
+	}
+	// Find the changes to the preferred frontier: O(|Preferred Frontier|)
+	for key := range ta.preferred {
+		if !previouslyPreferred[key] {
+			ta.preferenceAdded.Add(ids.NewID(key))
+		}
+	}
+	for key := range previouslyPreferred {
+		if !ta.preferred[key] {
+			ta.preferenceRemoved.Add(ids.NewID(key))
+		}
+	}
+
+	result.Accepted = int(ta.stats.accepted - numAccepted)
+	result.Rejected = int(ta.stats.rejected - numRejected)
+	return result
+}
+
+// numVotedVertices returns the number of live vertices that contain a
+// processing transaction in [txIDs]
+func (ta *Topological) numVotedVertices(txIDs ids.Set) int {
+	if txIDs.Len() == 0 {
+		return 0
+	}
+
+	numVertices := 0
+	for _, vtx := range ta.nodes {
+		for _, tx := range vtx.Txs() {
+			if tx.Status() == choices.Processing && txIDs.Contains(tx.ID()) {
+				numVertices++
+				break
+			}
+		}
+	}
+	return numVertices
+}
+
+// FilterResponses returns the subset of [responses] that vote for live
+// vertices. Votes for vertices that aren't live would be dropped by RecordPoll,
+// so filtering them out beforehand avoids wasted work.
+func (ta *Topological) FilterResponses(responses ids.UniqueBag) ids.UniqueBag {
+	filtered := make(ids.UniqueBag, len(responses))
+	for key, voters := range responses {
+		if _, live := ta.nodes[key]; live {
+			filtered[key] = voters
+		}
+	}
+	return filtered
+}
+
+// RecordPollWithID records the poll in the same manner as RecordPoll, unless a
+// poll with the same [pollID] was recorded within the last pollReplayWindow
+// polls recorded with an ID. Returns true if the poll was recorded.
+func (ta *Topological) RecordPollWithID(pollID uint32, responses ids.UniqueBag) bool {
+	if ta.recentPollIDs[pollID] {
+		ta.ctx.Log.Debug("Ignoring repeated delivery of poll %d", pollID)
+		return false
+	}
+
+	ta.recentPollIDs[pollID] = true
+	ta.recentPollOrder = append(ta.recentPollOrder, pollID)
+	if len(ta.recentPollOrder) > pollReplayWindow {
+		delete(ta.recentPollIDs, ta.recentPollOrder[0])
+		ta.recentPollOrder = ta.recentPollOrder[1:]
+	}
+
+	ta.recordPollEvent(Event{Type: RecordPollWithIDEvent, PollID: pollID}, responses)
+	ta.recordPoll(responses, nil, 1)
+	return true
+}
+
+// AcceptRate returns the average number of vertices accepted per second over
+// the last acceptRateWindow
+func (ta *Topological) AcceptRate() float64 {
+	ta.acceptTimes = ta.pruneAcceptTimes()
+	return float64(len(ta.acceptTimes)) / acceptRateWindow.Seconds()
+}
+
+// Returns the accept times that are still within the acceptRateWindow
+func (ta *Topological) pruneAcceptTimes() []time.Time {
+	cutoff := ta.clock.Time().Add(-acceptRateWindow)
+	i := 0
+	for i < len(ta.acceptTimes) && !ta.acceptTimes[i].After(cutoff) {
+		i++
+	}
+	return ta.acceptTimes[i:]
+}
+
+// PreferenceDelta returns the vertices that were added to and removed from the
+// preferred frontier during the most recent call to RecordPoll
+func (ta *Topological) PreferenceDelta() (added, removed ids.Set) {
+	return ta.preferenceAdded, ta.preferenceRemoved
+}
+
+// LastAcceptedTxs returns the IDs of the transactions that were accepted during
+// the most recent call to RecordPoll
+func (ta *Topological) LastAcceptedTxs() []ids.ID { return ta.lastAcceptedTxs }
+
+// StuckVirtuousTxs returns the IDs of the virtuous transactions that have been
+// processing for more than [maxPolls] polls. A virtuous transaction should
+// always finalize eventually, so a stuck virtuous transaction indicates a
+// liveness failure.
+func (ta *Topological) StuckVirtuousTxs(maxPolls int) []ids.ID {
+	stuck := []ids.ID(nil)
+	for key, polls := range ta.virtuousTxPolls {
+		if polls > maxPolls {
+			stuck = append(stuck, ids.NewID(key))
+		}
+	}
+	ids.SortIDs(stuck)
+	return stuck
+}
+
+// StateFingerprint returns a hash of the live vertices and the frontier. Two
+// instances with identical live state will report identical fingerprints,
+// which allows for detecting when the consensus state of nodes has diverged.
+func (ta *Topological) StateFingerprint() ids.ID {
+	liveIDs := make([]ids.ID, 0, len(ta.nodes))
+	for key := range ta.nodes {
+		liveIDs = append(liveIDs, ids.NewID(key))
+	}
+	ids.SortIDs(liveIDs)
+
+	frontierIDs := make([]ids.ID, 0, len(ta.frontier))
+	for key := range ta.frontier {
+		frontierIDs = append(frontierIDs, ids.NewID(key))
+	}
+	ids.SortIDs(frontierIDs)
+
+	packer := wrappers.Packer{
+		Bytes: make([]byte, 2*wrappers.IntLen+(len(liveIDs)+len(frontierIDs))*hashing.HashLen),
+	}
+	packer.PackInt(uint32(len(liveIDs)))
+	for _, vtxID := range liveIDs {
+		packer.PackFixedBytes(vtxID.Bytes())
+	}
+	packer.PackInt(uint32(len(frontierIDs)))
+	for _, vtxID := range frontierIDs {
+		packer.PackFixedBytes(vtxID.Bytes())
+	}
+	return ids.NewID(hashing.ComputeHash256Array(packer.Bytes))
 }
 
 // Quiesce implements the Avalanche interface
@@ -150,40 +1027,97 @@ func (ta *Topological) Quiesce() bool { return ta.cg.Quiesce() }
 // Finalized implements the Avalanche interface
 func (ta *Topological) Finalized() bool { return ta.cg.Finalized() }
 
+// PendingWork implements the Avalanche interface
+func (ta *Topological) PendingWork() int {
+	_, virtuousTxs := ta.cgSets()
+	pending := 0
+	for _, tx := range ta.processingTxs() {
+		beta := ta.params.BetaRogue
+		if virtuousTxs.Contains(tx.ID()) {
+			beta = ta.params.BetaVirtuous
+		}
+		if ta.cg.Confidence(tx) < beta {
+			pending++
+		}
+	}
+	return pending
+}
+
+// tracedKahnVotes collects the votes for each transaction by topologically
+// sorting the voted for vertices and their live ancestors
+func (ta *Topological) tracedKahnVotes(pollSpan Span, responses ids.UniqueBag) (*ids.Bag, error) {
+	// Set up the topological sort: O(|Live Set|)
+	span := ta.startSpan(pollSpan, "calculateInDegree")
+	kahns, leaves, err := ta.calculateInDegree(responses)
+	setSpanAttribute(span, "vertices", len(kahns))
+	setSpanAttribute(span, "leaves", len(leaves))
+	endSpan(span)
+	if err != nil {
+		return nil, err
+	}
+	// Push the votes to the ancestors: O(|Live Set|)
+	span = ta.startSpan(pollSpan, "pushVotes")
+	votes := ta.pushVotes(kahns, leaves, ta.alpha(responses))
+	setSpanAttribute(span, "votes", votes.Len())
+	endSpan(span)
+	return votes, nil
+}
+
 // Takes in a list of votes and sets up the topological ordering. Returns the
 // reachable section of the graph annotated with the number of inbound edges and
 // the non-transitively applied votes. Also returns the list of leaf nodes.
 func (ta *Topological) calculateInDegree(
-	responses ids.UniqueBag) (map[[32]byte]kahnNode, []ids.ID) {
-	kahns := make(map[[32]byte]kahnNode)
-	leaves := ids.Set{}
+	responses ids.UniqueBag) (map[[32]byte]kahnNode, []ids.ID, error) {
+	// Every voted for vertex is a node, and possibly a leaf, so size the
+	// structures up front to avoid growing them during the traversal.
+	kahns := make(map[[32]byte]kahnNode, len(responses))
+	leaves := ids.GetSet()
+	defer ids.PutSet(leaves)
+	wastedVotes := 0
+	lateVotes := 0
 
 	for _, vote := range responses.List() {
 		key := vote.Key()
 		// If it is not found, then the vote is either for something decided,
 		// or something we haven't heard of yet.
-		if vtx := ta.nodes[key]; vtx != nil {
+		if vtx := ta.nodes[key]; vtx == nil {
+			numVotes := responses.GetSet(vote).Len()
+			wastedVotes += numVotes
+			// Votes for a vertex that was decided after the query was sent
+			// can only be identified while the decision is retained.
+			if ta.decisions.contains(vote) {
+				lateVotes += numVotes
+			}
+		} else {
 			kahn, previouslySeen := kahns[key]
-			// Add this new vote to the current bag of votes
-			kahn.votes.Union(responses.GetSet(vote))
+			// Add this new vote to the current bag of votes. Every voter must
+			// have been one of the K sampled validators.
+			if err := kahn.votes.UnionChecked(responses.GetSet(vote), uint(ta.params.K)); err != nil {
+				return nil, nil, err
+			}
 			kahns[key] = kahn
 
 			if !previouslySeen {
 				// If I've never seen this node before, it is currently a leaf.
 				leaves.Add(vote)
-				ta.markAncestorInDegrees(kahns, leaves, vtx.Parents())
+				if err := ta.markAncestorInDegrees(kahns, leaves, ta.parents(vtx)); err != nil {
+					return nil, nil, err
+				}
 			}
 		}
 	}
 
-	return kahns, leaves.List()
+	ta.metrics.WastedVotes(wastedVotes)
+	ta.metrics.LateVotes(lateVotes)
+	return kahns, leaves.List(), nil
 }
 
-// adds a new in-degree reference for all nodes
+// adds a new in-degree reference for all nodes. Errors if the in-degree of a
+// node would exceed maxInDegree.
 func (ta *Topological) markAncestorInDegrees(
 	kahns map[[32]byte]kahnNode,
 	leaves ids.Set,
-	deps []Vertex) (map[[32]byte]kahnNode, ids.Set) {
+	deps []Vertex) error {
 	frontier := []Vertex{}
 	for _, vtx := range deps {
 		// The vertex may have been decided, no need to vote in that case
@@ -200,6 +1134,9 @@ func (ta *Topological) markAncestorInDegrees(
 		currentID := current.ID()
 		currentKey := currentID.Key()
 		kahn, alreadySeen := kahns[currentKey]
+		if kahn.inDegree >= maxInDegree {
+			return fmt.Errorf("%w for vertex %s", errInDegreeOverflow, currentID)
+		}
 		// I got here through a transitive edge, so increase the in-degree
 		kahn.inDegree++
 		kahns[currentKey] = kahn
@@ -213,7 +1150,7 @@ func (ta *Topological) markAncestorInDegrees(
 		if !alreadySeen {
 			// If I am seeing this node for the first time, I need to check its
 			// parents
-			for _, depVtx := range current.Parents() {
+			for _, depVtx := range ta.parents(current) {
 				// No need to traverse to a decided vertex
 				if !depVtx.Status().Decided() {
 					frontier = append(frontier, depVtx)
@@ -221,14 +1158,37 @@ func (ta *Topological) markAncestorInDegrees(
 			}
 		}
 	}
-	return kahns, leaves
+	return nil
+}
+
+// Returns the number of votes a transaction must receive in this poll for its
+// confidence to increase
+func (ta *Topological) alpha(responses ids.UniqueBag) int {
+	if ta.params.AlphaFraction <= 0 {
+		return ta.params.Alpha
+	}
+
+	responders := ids.BitSet(0)
+	for _, bs := range responses {
+		responders.Union(bs)
+	}
+	alpha := int(math.Ceil(ta.params.AlphaFraction * float64(responders.Len())))
+	if alpha < 1 {
+		alpha = 1
+	}
+	return alpha
 }
 
-// count the number of votes for each operation
+// count the number of votes for each operation. The returned bag is pooled, so
+// it must be returned with ids.PutBag once it is no longer needed.
 func (ta *Topological) pushVotes(
 	kahnNodes map[[32]byte]kahnNode,
-	leaves []ids.ID) ids.Bag {
-	votes := make(ids.UniqueBag)
+	leaves []ids.ID,
+	alpha int) *ids.Bag {
+	// BitSets are stored by value, so there is nothing to gain from sharing
+	// identical vote sets between consumers. Instead, the bag is sized for one
+	// consumer per voted for vertex, which is the common case.
+	votes := make(ids.UniqueBag, len(kahnNodes))
 
 	for len(leaves) > 0 {
 		newLeavesSize := len(leaves) - 1
@@ -240,12 +1200,14 @@ func (ta *Topological) pushVotes(
 
 		if vtx := ta.nodes[key]; vtx != nil {
 			for _, tx := range vtx.Txs() {
-				// Give the votes to the consumer
+				// Give the votes to the consumer. If the consumer is in
+				// multiple vertices, it receives the union of their votes, so
+				// each validator is counted at most once per consumer.
 				txID := tx.ID()
 				votes.UnionSet(txID, kahn.votes)
 			}
 
-			for _, dep := range vtx.Parents() {
+			for _, dep := range ta.parents(vtx) {
 				depID := dep.ID()
 				depKey := depID.Key()
 				if depNode, notPruned := kahnNodes[depKey]; notPruned {
@@ -263,9 +1225,45 @@ func (ta *Topological) pushVotes(
 		}
 	}
 
-	return votes.Bag(ta.params.Alpha)
+	return ta.voteBag(votes, alpha)
+}
+
+// voteBag converts the sets of validators that voted for each transaction into
+// the bag of votes given to the conflict graph. The returned bag is pooled, so
+// it must be returned with ids.PutBag once it is no longer needed.
+func (ta *Topological) voteBag(votes ids.UniqueBag, alpha int) *ids.Bag {
+	bag := ids.GetBag()
+	bag.SetThreshold(ta.params.Alpha)
+	for key, voters := range votes {
+		switch {
+		case alpha == ta.params.Alpha:
+			bag.AddCount(ids.NewID(key), voters.Len())
+		case voters.Len() >= alpha:
+			// The conflict graph applies the fixed alpha to the votes it is
+			// given. So, only the txs that met the effective alpha are
+			// reported, and they are reported as having met the fixed alpha.
+			bag.AddCount(ids.NewID(key), ta.params.Alpha)
+		}
+	}
+	return bag
+}
+
+// updateFrame is a vertex whose parents are being updated by update
+type updateFrame struct {
+	vtx  Vertex
+	txs  []snowstorm.Tx
+	deps []Vertex
+	// Index of the next parent to update
+	next int
+
+	acceptable, rejectable, preferred, virtuous bool
 }
 
+// update updates [vtx] and its ancestry. Each vertex is updated once all of
+// its parents have been updated, in the same order as a depth first traversal
+// of the parents. The traversal uses an explicit stack, so a deep ancestry
+// can't exhaust the goroutine's stack.
+//
 // If I've already checked, do nothing
 // If I'm decided, cache the preference and return
 // At this point, I must be live
@@ -276,10 +1274,36 @@ func (ta *Topological) pushVotes(
 //     myself to the preferred frontier
 // If all my parents are accepted and I'm acceptable, accept myself
 func (ta *Topological) update(vtx Vertex) {
+	stack := []updateFrame(nil)
+	if frame, ok := ta.startUpdate(vtx); ok {
+		stack = append(stack, frame)
+	}
+	for len(stack) > 0 {
+		frame := &stack[len(stack)-1]
+		if frame.next < len(frame.deps) {
+			// Update my next dependency before I'm updated
+			dep := frame.deps[frame.next]
+			frame.next++
+			if depFrame, ok := ta.startUpdate(dep); ok {
+				stack = append(stack, depFrame)
+			}
+			continue
+		}
+
+		// All of my dependencies have been updated
+		ta.finishUpdate(frame)
+		stack = stack[:len(stack)-1]
+	}
+}
+
+// startUpdate returns the frame needed to update [vtx] once its dependencies
+// have been updated. If [vtx] doesn't need to be updated after its
+// dependencies, false is returned.
+func (ta *Topological) startUpdate(vtx Vertex) (updateFrame, bool) {
 	vtxID := vtx.ID()
 	vtxKey := vtxID.Key()
 	if _, cached := ta.preferenceCache[vtxKey]; cached {
-		return // This vertex has already been updated
+		return updateFrame{}, false // This vertex has already been updated
 	}
 
 	switch vtx.Status() {
@@ -291,56 +1315,79 @@ func (ta *Topological) update(vtx Vertex) {
 
 		ta.preferenceCache[vtxKey] = true
 		ta.virtuousCache[vtxKey] = true
-		return
+		return updateFrame{}, false
 	case choices.Rejected:
 		// I'm rejected
 		ta.preferenceCache[vtxKey] = false
 		ta.virtuousCache[vtxKey] = false
-		return
+		return updateFrame{}, false
 	}
 
-	acceptable := true  // If the batch is accepted, this vertex is acceptable
-	rejectable := false // If I'm rejectable, I must be rejected
-	preferred := true
-	virtuous := true
-	txs := vtx.Txs()
-	preferences := ta.cg.Preferences()
-	virtuousTxs := ta.cg.Virtuous()
+	frame := updateFrame{
+		vtx:        vtx,
+		txs:        vtx.Txs(),
+		acceptable: true,  // If the batch is accepted, this vertex is acceptable
+		rejectable: false, // If I'm rejectable, I must be rejected
+		preferred:  true,
+		virtuous:   true,
+	}
+	preferences, virtuousTxs := ta.cgSets()
 
-	for _, tx := range txs {
+	for _, tx := range frame.txs {
 		txID := tx.ID()
 		s := tx.Status()
 		if s == choices.Rejected {
 			// If I contain a rejected consumer, I am rejectable
-			rejectable = true
-			preferred = false
-			virtuous = false
+			frame.rejectable = true
+			frame.preferred = false
+			frame.virtuous = false
 		}
 		if s != choices.Accepted {
 			// If I contain a non-accepted consumer, I am not acceptable
-			acceptable = false
-			preferred = preferred && preferences.Contains(txID)
-			virtuous = virtuous && virtuousTxs.Contains(txID)
+			frame.acceptable = false
+			frame.preferred = frame.preferred && preferences.Contains(txID)
+			frame.virtuous = frame.virtuous && virtuousTxs.Contains(txID)
 		}
 	}
 
-	deps := vtx.Parents()
-	// Update all of my dependencies
-	for _, dep := range deps {
-		ta.update(dep)
+	frame.deps = ta.parents(vtx)
+	return frame, true
+}
 
-		depID := dep.ID()
-		key := depID.Key()
+// finishUpdate updates the vertex of [frame] after all of its dependencies
+// have been updated
+func (ta *Topological) finishUpdate(frame *updateFrame) {
+	vtx := frame.vtx
+	vtxID := vtx.ID()
+	vtxKey := vtxID.Key()
+	txs := frame.txs
+	deps := frame.deps
+	acceptable := frame.acceptable
+	rejectable := frame.rejectable
+	preferred := frame.preferred
+	virtuous := frame.virtuous
+
+	for _, dep := range deps {
+		key := dep.ID().Key()
 		preferred = preferred && ta.preferenceCache[key]
 		virtuous = virtuous && ta.virtuousCache[key]
 	}
 
 	// Check my parent statuses
 	for _, dep := range deps {
-		if status := dep.Status(); status == choices.Rejected {
+		if status := dep.Status(); status == choices.Rejected && ta.verifying {
+			// The incremental update should have already rejected me
+			ta.unexpectedDecisions.Add(vtxID)
+			ta.preferenceCache[vtxKey] = false
+			ta.virtuousCache[vtxKey] = false
+			return
+		} else if status == choices.Rejected {
 			vtx.Reject() // My parent is rejected, so I should be rejected
-			delete(ta.nodes, vtxKey)
+			ta.removeNode(vtx)
+			ta.restoreParents(vtx)
+			ta.decided(vtxID, choices.Rejected)
 			ta.metrics.Rejected(vtxID)
+			ta.rejected(vtx, RejectReasonParentRejected)
 
 			ta.preferenceCache[vtxKey] = false
 			ta.virtuousCache[vtxKey] = false
@@ -387,19 +1434,237 @@ func (ta *Topological) update(vtx Vertex) {
 	}
 
 	switch {
+	case ta.verifying && (acceptable || rejectable):
+		// The incremental update should have already decided me
+		ta.unexpectedDecisions.Add(vtxID)
+	case acceptable && ta.deferRogueAccepts && ta.containsRogueTx(txs):
+		// I'll be accepted once the virtuous vertices have been accepted
 	case acceptable:
 		// I'm acceptable, why not accept?
 		ta.ctx.ConsensusDispatcher.Accept(ta.ctx.ChainID, vtxID, vtx.Bytes())
 		vtx.Accept()
-		delete(ta.nodes, vtxKey)
+		ta.removeNode(vtx)
+		ta.decided(vtxID, choices.Accepted)
+		ta.acceptedFilter.add(vtxID)
+		ta.recentlyAccepted = append(ta.recentlyAccepted, vtxID)
+		ta.acceptTimes = append(ta.pruneAcceptTimes(), ta.clock.Time())
+		ta.health.lastAccept = ta.clock.Time()
 		ta.metrics.Accepted(vtxID)
+		ta.accepted(vtx)
+		ta.pruneAcceptedHistory(vtxID)
 	case rejectable:
 		// I'm rejectable, why not reject?
+		reason := RejectReasonConflict
+		if ta.issuedRejected.Contains(vtxID) {
+			reason = RejectReasonRejectedTx
+		}
+
 		vtx.Reject()
 		ta.ctx.ConsensusDispatcher.Reject(ta.ctx.ChainID, vtxID, vtx.Bytes())
-		delete(ta.nodes, vtxKey)
+		ta.removeNode(vtx)
+		ta.restoreParents(vtx)
+		ta.decided(vtxID, choices.Rejected)
 		ta.metrics.Rejected(vtxID)
+		ta.rejected(vtx, reason)
+	}
+}
+
+// Returns the processing txs contained in live vertices
+func (ta *Topological) processingTxs() map[[32]byte]snowstorm.Tx {
+	txs := make(map[[32]byte]snowstorm.Tx)
+	for _, vtx := range ta.nodes {
+		for _, tx := range vtx.Txs() {
+			if tx.Status() == choices.Processing {
+				txs[tx.ID().Key()] = tx
+			}
+		}
+	}
+	return txs
+}
+
+// Returns the IDs of the transactions in [txs] that conflict with another
+// transaction
+func (ta *Topological) rogueTxIDs(txs map[[32]byte]snowstorm.Tx) ids.Set {
+	rogueTxs := ids.Set{}
+	for _, tx := range txs {
+		if !ta.cg.IsVirtuous(tx) {
+			rogueTxs.Add(tx.ID())
+		}
+	}
+	return rogueTxs
+}
+
+// Returns true if any of [txs] were rogue at the start of the current poll
+func (ta *Topological) containsRogueTx(txs []snowstorm.Tx) bool {
+	for _, tx := range txs {
+		if ta.rogueTxs.Contains(tx.ID()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the preferred and virtuous txs of the conflict graph. The sets are
+// only fetched from the conflict graph once between modifications of it, as
+// they are read for every vertex that is updated.
+func (ta *Topological) cgSets() (preferences, virtuous ids.Set) {
+	if !ta.cgSetsCached {
+		ta.cgPreferences = ta.cg.Preferences()
+		ta.cgVirtuous = ta.cg.Virtuous()
+		ta.cgSetsCached = true
+	}
+	return ta.cgPreferences, ta.cgVirtuous
+}
+
+// Increments the number of polls each processing virtuous tx has been alive
+// for, and stops tracking txs that are no longer processing and virtuous
+func (ta *Topological) updateVirtuousTxPolls() {
+	_, virtuousTxs := ta.cgSets()
+	for key := range ta.virtuousTxPolls {
+		if !virtuousTxs[key] {
+			delete(ta.virtuousTxPolls, key)
+		}
+	}
+	for key := range virtuousTxs {
+		ta.virtuousTxPolls[key]++
+	}
+}
+
+// Removes a decided vertex from the set of live vertices
+func (ta *Topological) removeNode(vtx Vertex) {
+	vtxID := vtx.ID()
+	delete(ta.nodes, vtxID.Key())
+	ta.issuedRejected.Remove(vtxID)
+	for _, tx := range vtx.Txs() {
+		txKey := tx.ID().Key()
+		vtxIDs := ta.txVertices[txKey]
+		vtxIDs.Remove(vtxID)
+		if vtxIDs.Len() == 0 {
+			delete(ta.txVertices, txKey)
+			ta.rogueLiveTxs.Remove(tx.ID())
+		}
+	}
+	for _, parent := range ta.parents(vtx) {
+		parentKey := parent.ID().Key()
+		children := ta.children[parentKey]
+		children.Remove(vtxID)
+		if children.Len() == 0 {
+			delete(ta.children, parentKey)
+		}
+	}
+}
+
+// Parents of the removed vertex [vtx] that no longer have any live children
+// rejoin the frontier, otherwise they would never be updated again
+func (ta *Topological) restoreParents(vtx Vertex) {
+	for _, parent := range ta.parents(vtx) {
+		parentKey := parent.ID().Key()
+		if _, hasChildren := ta.children[parentKey]; hasChildren {
+			continue
+		}
+		if _, live := ta.nodes[parentKey]; live || parent.Status() == choices.Accepted {
+			ta.frontier[parentKey] = parent
+		}
+	}
+}
+
+// Returns the parents of [vtx], skipping any nil parents reported by a buggy
+// or byzantine vertex
+func (ta *Topological) parents(vtx Vertex) []Vertex {
+	parents := vtx.Parents()
+	for i, parent := range parents {
+		if parent != nil {
+			continue
+		}
+
+		ta.ctx.Log.Warn("Skipping nil parent of vertex %s", vtx.ID())
+		nonNil := append([]Vertex(nil), parents[:i]...)
+		for _, parent := range parents[i+1:] {
+			if parent != nil {
+				nonNil = append(nonNil, parent)
+			}
+		}
+		return nonNil
+	}
+	return parents
+}
+
+// Assigns the next acceptance sequence number and notifies the acceptance
+// handler, if there is one
+func (ta *Topological) accepted(vtx Vertex) {
+	vtxID := vtx.ID()
+	ta.acceptanceSequence++
+	ta.acceptedLog.add(vtxID, ta.acceptanceSequence)
+	if ta.params.OnAccept != nil {
+		ta.params.OnAccept(vtxID, vtx.Bytes(), ta.acceptanceSequence)
+	}
+}
+
+// Notifies the rejection handler, if there is one
+func (ta *Topological) rejected(vtx Vertex, reason string) {
+	if ta.params.OnReject != nil {
+		ta.params.OnReject(vtx.ID(), vtx.Bytes(), reason)
+	}
+}
+
+// verifyFrontiers recomputes the frontier sets starting from every live vertex,
+// rather than from only the previous frontier, and returns an error if the
+// result differs from the incrementally updated frontier sets. The recomputed
+// sets are kept.
+func (ta *Topological) verifyFrontiers() error {
+	preferred, virtuous, orphans := ta.preferred, ta.virtuous, ta.orphans
+	frontier := ta.frontier
+
+	seeds := make(map[[32]byte]Vertex, len(frontier)+len(ta.nodes))
+	for key, vtx := range frontier {
+		seeds[key] = vtx
+	}
+	for key, vtx := range ta.nodes {
+		seeds[key] = vtx
+	}
+
+	ta.verifying = true
+	ta.unexpectedDecisions = nil
+	ta.frontier = seeds
+	ta.updateFrontiers()
+	ta.verifying = false
+
+	// Decided vertices may linger in the frontier sets depending on the order
+	// the frontier is traversed in, so only live vertices are compared
+	frontierIDs := ids.Set{}
+	for key := range frontier {
+		frontierIDs.Add(ids.NewID(key))
+	}
+	recomputedFrontierIDs := ids.Set{}
+	for key := range ta.frontier {
+		recomputedFrontierIDs.Add(ids.NewID(key))
+	}
+
+	switch {
+	case ta.unexpectedDecisions.Len() > 0:
+		return fmt.Errorf("incremental frontier update left decidable vertices %s", ta.unexpectedDecisions)
+	case !ta.liveIDs(preferred).Equals(ta.liveIDs(ta.preferred)):
+		return fmt.Errorf("incremental preferred frontier %s differs from %s", preferred, ta.preferred)
+	case !ta.liveIDs(virtuous).Equals(ta.liveIDs(ta.virtuous)):
+		return fmt.Errorf("incremental virtuous frontier %s differs from %s", virtuous, ta.virtuous)
+	case !ta.orphans.Equals(orphans):
+		return fmt.Errorf("incremental orphans %s differ from %s", orphans, ta.orphans)
+	case !ta.liveIDs(frontierIDs).Equals(ta.liveIDs(recomputedFrontierIDs)):
+		return fmt.Errorf("incremental frontier %s differs from %s", frontierIDs, recomputedFrontierIDs)
+	default:
+		return nil
+	}
+}
+
+// liveIDs returns the subset of [vtxIDs] that are still processing
+func (ta *Topological) liveIDs(vtxIDs ids.Set) ids.Set {
+	live := ids.Set{}
+	for _, vtxID := range vtxIDs.List() {
+		if _, ok := ta.nodes[vtxID.Key()]; ok {
+			live.Add(vtxID)
+		}
 	}
+	return live
 }
 
 // Update the frontier sets
@@ -413,10 +1678,12 @@ func (ta *Topological) updateFrontiers() {
 	ta.preferenceCache = make(map[[32]byte]bool)
 	ta.virtuousCache = make(map[[32]byte]bool)
 
-	ta.orphans.Union(ta.cg.Virtuous()) // Initially, nothing is preferred
+	_, virtuousTxs := ta.cgSets()
+	ta.orphans.Union(virtuousTxs) // Initially, nothing is preferred
 
 	for _, vtx := range vts {
 		// Update all the vertices that were in my previous frontier
 		ta.update(vtx)
 	}
+	ta.metrics.Orphans(ta.orphans.Len())
 }
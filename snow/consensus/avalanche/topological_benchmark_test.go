@@ -0,0 +1,79 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+// BenchmarkRecordPollWideDAG benchmarks polls over a DAG with many live
+// vertices that share the same parents
+func BenchmarkRecordPollWideDAG(b *testing.B) {
+	const (
+		numVts = 1000
+		k      = 20
+	)
+
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 k,
+			Alpha:             k/2 + 1,
+			BetaVirtuous:      b.N + 1, // Never finalize during the benchmark
+			BetaRogue:         b.N + 1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	genesis := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, genesis)
+
+	vtxIDs := make([]ids.ID, numVts)
+	for i := range vtxIDs {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+
+		vtx := &Vtx{
+			dependencies: genesis,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       1,
+			status:       choices.Processing,
+		}
+		ta.Add(vtx)
+		vtxIDs[i] = vtx.id
+	}
+
+	// Every validator votes for every live vertex
+	votes := ids.UniqueBag{}
+	for i := uint(0); i < k; i++ {
+		votes.Add(i, vtxIDs...)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		ta.RecordPoll(votes)
+	}
+}
@@ -4,8 +4,11 @@
 package avalanche
 
 import (
+	"bytes"
 	"math"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -759,3 +762,3803 @@ func TestAvalancheOrphans(t *testing.T) {
 		t.Fatalf("Wrong orphan")
 	}
 }
+
+// metricValue returns the value of the named counter or gauge, or the sample
+// count of the named histogram
+func metricValue(t *testing.T, registry *prometheus.Registry, name string) float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		metric := family.GetMetric()[0]
+		switch {
+		case metric.GetCounter() != nil:
+			return metric.GetCounter().GetValue()
+		case metric.GetGauge() != nil:
+			return metric.GetGauge().GetValue()
+		case metric.GetHistogram() != nil:
+			return float64(metric.GetHistogram().GetSampleCount())
+		}
+	}
+	t.Fatalf("Metric %s not registered", name)
+	return 0
+}
+
+func TestAvalancheWastedVotes(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           registry,
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	if wasted := metricValue(t, registry, "vtx_wasted_votes"); wasted != 0 {
+		t.Fatalf("Reported %f wasted votes before any polls", wasted)
+	}
+
+	sm := make(ids.UniqueBag)
+	sm.Add(0, GenerateID())
+	sm.Add(1, vts[0].ID())
+	ta.RecordPoll(sm)
+
+	if wasted := metricValue(t, registry, "vtx_wasted_votes"); wasted != 2 {
+		t.Fatalf("Reported %f wasted votes, expected %d", wasted, 2)
+	}
+}
+
+func TestAvalancheLateVotes(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           registry,
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:           2,
+		BatchSize:         1,
+		DecisionRetention: time.Minute,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	sm := make(ids.UniqueBag)
+	sm.Add(0, vtx0.id)
+	sm.Add(1, vtx0.id)
+	ta.RecordPoll(sm)
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if late := metricValue(t, registry, "vtx_late_votes"); late != 0 {
+		t.Fatalf("Reported %f late votes, expected %d", late, 0)
+	}
+
+	// The response to a query issued before the vertex was accepted
+	sm = make(ids.UniqueBag)
+	sm.Add(0, vtx0.id)
+	sm.Add(1, vtx0.id, GenerateID())
+	ta.RecordPoll(sm)
+
+	if late := metricValue(t, registry, "vtx_late_votes"); late != 2 {
+		t.Fatalf("Reported %f late votes, expected %d", late, 2)
+	} else if wasted := metricValue(t, registry, "vtx_wasted_votes"); wasted != 3 {
+		t.Fatalf("Reported %f wasted votes, expected %d", wasted, 3)
+	}
+}
+
+func TestAvalanchePreferredChild(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      3,
+			BetaRogue:         3,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID(), GenerateID()}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxos[0])
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[0])
+
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(utxos[1])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx2 := &Vtx{
+		dependencies: []Vertex{vtx0},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       2,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	if childID, ok := ta.PreferredChild(vts[0].ID()); !ok {
+		t.Fatalf("Should have found a preferred child")
+	} else if !childID.Equals(vtx0.id) {
+		t.Fatalf("Preferred child should have been %s, got %s", vtx0.id, childID)
+	}
+
+	if childID, ok := ta.PreferredChild(vtx0.id); !ok {
+		t.Fatalf("Should have found a preferred child")
+	} else if !childID.Equals(vtx2.id) {
+		t.Fatalf("Preferred child should have been %s, got %s", vtx2.id, childID)
+	}
+
+	if _, ok := ta.PreferredChild(vtx1.id); ok {
+		t.Fatalf("Vertex without children shouldn't have a preferred child")
+	}
+
+	sm := make(ids.UniqueBag)
+	sm.Add(0, vtx1.id)
+	ta.RecordPoll(sm)
+
+	if childID, ok := ta.PreferredChild(vts[0].ID()); !ok {
+		t.Fatalf("Should have found a preferred child")
+	} else if !childID.Equals(vtx1.id) {
+		t.Fatalf("Preferred child should have been %s, got %s", vtx1.id, childID)
+	}
+
+	if _, ok := ta.PreferredChild(vtx0.id); ok {
+		t.Fatalf("Vertex with only unpreferred children shouldn't have a preferred child")
+	}
+}
+
+type panickingParentsVtx struct{ *Vtx }
+
+func (*panickingParentsVtx) Parents() []Vertex { panic("malformed parents") }
+
+func TestAvalancheSafeAdd(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	vtx := &panickingParentsVtx{Vtx: &Vtx{
+		id:     GenerateID(),
+		height: 1,
+		status: choices.Processing,
+	}}
+
+	if err := ta.SafeAdd(vtx); err == nil {
+		t.Fatalf("Should have reported the panic as an error")
+	}
+
+	if err := ta.SafeAdd(nil); err == nil {
+		t.Fatalf("Should have reported adding a nil vertex")
+	}
+
+	tx := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx.Ins.Add(GenerateID())
+
+	goodVtx := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	if err := ta.SafeAdd(goodVtx); err != nil {
+		t.Fatal(err)
+	} else if !ta.VertexIssued(goodVtx) {
+		t.Fatalf("Well formed vertex should have been issued")
+	}
+}
+
+func TestAvalancheStuckVirtuousTxs(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	// A single vote never reaches alpha, so the tx can't make progress
+	sm := make(ids.UniqueBag)
+	sm.Add(0, vtx0.id)
+	for i := 0; i < 3; i++ {
+		ta.RecordPoll(sm)
+	}
+
+	if stuck := ta.StuckVirtuousTxs(5); len(stuck) != 0 {
+		t.Fatalf("Reported %d stuck txs before the limit", len(stuck))
+	} else if stuck := ta.StuckVirtuousTxs(2); !ids.UnsortedEquals([]ids.ID{tx0.ID()}, stuck) {
+		t.Fatalf("Should have reported %s as stuck, got %v", tx0.ID(), stuck)
+	}
+
+	sm.Add(1, vtx0.id)
+	ta.RecordPoll(sm)
+
+	if tx0.Status() != choices.Accepted {
+		t.Fatalf("Tx should have been accepted")
+	} else if stuck := ta.StuckVirtuousTxs(0); len(stuck) != 0 {
+		t.Fatalf("Accepted tx shouldn't be reported as stuck")
+	}
+}
+
+func TestAvalancheAlphaFraction(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 4,
+			Alpha:             3,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:       2,
+		BatchSize:     1,
+		AlphaFraction: .6,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	// All four validators respond, so three votes are required
+	sm := make(ids.UniqueBag)
+	sm.Add(0, vtx0.id)
+	sm.Add(1, vtx0.id)
+	sm.Add(2, GenerateID())
+	sm.Add(3, GenerateID())
+	ta.RecordPoll(sm)
+
+	if tx0.Status() != choices.Processing {
+		t.Fatalf("Tx shouldn't have been accepted with 2 of 4 votes")
+	}
+
+	// Only two validators respond, so two votes are sufficient, even though
+	// that is less than the fixed alpha
+	sm = make(ids.UniqueBag)
+	sm.Add(0, vtx0.id)
+	sm.Add(1, vtx0.id)
+	ta.RecordPoll(sm)
+
+	if tx0.Status() != choices.Accepted {
+		t.Fatalf("Tx should have been accepted with 2 of 2 votes")
+	} else if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	}
+}
+
+func TestAvalancheConflictGraph(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID()}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxos[0])
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[0])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	cg := ta.ConflictGraph()
+	if cg == nil {
+		t.Fatalf("Conflict graph should have been initialized")
+	} else if cg.Issued(tx0) != ta.TxIssued(tx0) {
+		t.Fatalf("Conflict graph disagrees on whether tx0 was issued")
+	} else if cg.Issued(tx1) != ta.TxIssued(tx1) {
+		t.Fatalf("Conflict graph disagrees on whether tx1 was issued")
+	} else if cg.IsVirtuous(tx1) != ta.IsVirtuous(tx1) {
+		t.Fatalf("Conflict graph disagrees on whether tx1 is virtuous")
+	}
+
+	if virtuous := cg.Virtuous(); !virtuous.Contains(tx0.ID()) {
+		t.Fatalf("Conflict graph should report tx0 as virtuous")
+	}
+}
+
+func TestAvalancheStateFingerprint(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID(), GenerateID()}
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxos[0])
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[1])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	params.Metrics = prometheus.NewRegistry()
+	ta0 := Topological{}
+	ta0.Initialize(snow.DefaultContextTest(), params, vts)
+
+	params.Metrics = prometheus.NewRegistry()
+	ta1 := Topological{}
+	ta1.Initialize(snow.DefaultContextTest(), params, vts)
+
+	if !ta0.StateFingerprint().Equals(ta1.StateFingerprint()) {
+		t.Fatalf("Identically initialized instances should have the same fingerprint")
+	}
+
+	ta0.Add(vtx0)
+	ta0.Add(vtx1)
+	ta1.Add(vtx1)
+	ta1.Add(vtx0)
+
+	if !ta0.StateFingerprint().Equals(ta1.StateFingerprint()) {
+		t.Fatalf("Identically driven instances should have the same fingerprint")
+	}
+
+	params.Metrics = prometheus.NewRegistry()
+	ta2 := Topological{}
+	ta2.Initialize(snow.DefaultContextTest(), params, vts)
+	ta2.Add(vtx0)
+
+	if ta0.StateFingerprint().Equals(ta2.StateFingerprint()) {
+		t.Fatalf("Instances with different live vertices should have different fingerprints")
+	}
+}
+
+func TestAvalanchePreferencesWithConfidence(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      5,
+			BetaRogue:         5,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID(), GenerateID()}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxos[0])
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[1])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0, tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	// Both votes are transitively applied to tx1, but tx0 only gets one
+	sm := make(ids.UniqueBag)
+	sm.Add(0, vtx1.id)
+	ta.RecordPoll(sm)
+
+	sm = make(ids.UniqueBag)
+	sm.Add(0, vtx0.id)
+	ta.RecordPoll(sm)
+
+	sm = make(ids.UniqueBag)
+	sm.Add(0, vtx1.id)
+	ta.RecordPoll(sm)
+
+	confidences := ta.PreferencesWithConfidence()
+	if len(confidences) != 2 {
+		t.Fatalf("Reported %d preferred vertices, expected %d", len(confidences), 2)
+	} else if confidence := confidences[vtx0.id.Key()]; confidence != 0 {
+		t.Fatalf("Vertex confidence should have been %d, got %d", 0, confidence)
+	} else if confidence := confidences[vtx1.id.Key()]; confidence != 3 {
+		t.Fatalf("Vertex confidence should have been %d, got %d", 3, confidence)
+	}
+}
+
+func TestAvalancheInitializeErrors(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Namespace:         "gecko",
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta0 := Topological{}
+	if err := ta0.Initialize(snow.DefaultContextTest(), params, vts); err != nil {
+		t.Fatal(err)
+	}
+
+	ta1 := Topological{}
+	if err := ta1.Initialize(snow.DefaultContextTest(), params, vts); err == nil {
+		t.Fatalf("Should have errored due to a metrics namespace collision")
+	}
+
+	params.Metrics = prometheus.NewRegistry()
+	params.Parents = 1
+	ta2 := Topological{}
+	if err := ta2.Initialize(snow.DefaultContextTest(), params, vts); err == nil {
+		t.Fatalf("Should have errored due to invalid parameters")
+	}
+}
+
+func TestAvalancheOnReject(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID(), GenerateID()}
+
+	reasons := map[[32]byte]string{}
+	params.OnReject = func(vtxID ids.ID, _ []byte, reason string) {
+		if _, exists := reasons[vtxID.Key()]; exists {
+			t.Fatalf("Vertex %s was rejected twice", vtxID)
+		}
+		reasons[vtxID.Key()] = reason
+	}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	rejectedTx := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Rejected,
+	}
+	rejectedTx.Ins.Add(utxos[0])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{rejectedTx},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	if reason := reasons[vtx0.id.Key()]; reason != RejectReasonRejectedTx {
+		t.Fatalf("Rejection reason should have been %q, got %q", RejectReasonRejectedTx, reason)
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[1])
+
+	vtx1 := &Vtx{
+		dependencies: []Vertex{vtx0},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       2,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx1)
+
+	if reason := reasons[vtx1.id.Key()]; reason != RejectReasonParentRejected {
+		t.Fatalf("Rejection reason should have been %q, got %q", RejectReasonParentRejected, reason)
+	}
+
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(utxos[1])
+
+	vtx2 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx3 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx3.Ins.Add(utxos[1])
+
+	vtx3 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx3},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx2)
+	ta.Add(vtx3)
+
+	sm := make(ids.UniqueBag)
+	sm.Add(0, vtx3.id)
+	ta.RecordPoll(sm)
+
+	if vtx2.Status() != choices.Rejected {
+		t.Fatalf("Vertex should have been rejected")
+	} else if reason := reasons[vtx2.id.Key()]; reason != RejectReasonConflict {
+		t.Fatalf("Rejection reason should have been %q, got %q", RejectReasonConflict, reason)
+	} else if len(reasons) != 3 {
+		t.Fatalf("Reported %d rejections, expected %d", len(reasons), 3)
+	}
+}
+
+func TestAvalancheInitializeStream(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	params.Metrics = prometheus.NewRegistry()
+	ta0 := Topological{}
+	if err := ta0.Initialize(snow.DefaultContextTest(), params, vts); err != nil {
+		t.Fatal(err)
+	}
+
+	i := 0
+	params.Metrics = prometheus.NewRegistry()
+	ta1 := Topological{}
+	if err := ta1.InitializeStream(snow.DefaultContextTest(), params, func() (Vertex, bool) {
+		if i >= len(vts) {
+			return nil, false
+		}
+		vtx := vts[i]
+		i++
+		return vtx, true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if i != len(vts) {
+		t.Fatalf("Should have pulled %d vertices, pulled %d", len(vts), i)
+	}
+	if !ta0.StateFingerprint().Equals(ta1.StateFingerprint()) {
+		t.Fatalf("Streamed initialization should match slice initialization")
+	}
+	if !ta0.Virtuous().Equals(ta1.Virtuous()) {
+		t.Fatalf("Wrong virtuous frontier. Expected %s got %s", ta0.Virtuous(), ta1.Virtuous())
+	}
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta0.Add(vtx0)
+	ta1.Add(vtx0)
+
+	if !ta0.StateFingerprint().Equals(ta1.StateFingerprint()) {
+		t.Fatalf("Streamed initialization should behave like slice initialization")
+	}
+	if !ta0.Preferences().Equals(ta1.Preferences()) {
+		t.Fatalf("Wrong preferences. Expected %s got %s", ta0.Preferences(), ta1.Preferences())
+	}
+}
+
+func TestAvalancheTxInLiveVtx(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID(), GenerateID(), GenerateID()}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	rejectedTx := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Rejected,
+	}
+	rejectedTx.Ins.Add(utxos[0])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{rejectedTx},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[1])
+
+	vtx1 := &Vtx{
+		dependencies: []Vertex{vtx0},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       2,
+		status:       choices.Processing,
+	}
+
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(utxos[2])
+
+	vtx2 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	if vtx1.Status() != choices.Rejected {
+		t.Fatalf("Vertex with a rejected parent should have been rejected")
+	}
+
+	if !ta.TxIssued(tx1) {
+		t.Fatalf("Tx should have been issued")
+	} else if ta.TxInLiveVtx(tx1.ID()) {
+		t.Fatalf("Tx should not be in a live vertex")
+	} else if !ta.TxIssued(tx2) {
+		t.Fatalf("Tx should have been issued")
+	} else if !ta.TxInLiveVtx(tx2.ID()) {
+		t.Fatalf("Tx should be in a live vertex")
+	} else if ta.TxInLiveVtx(rejectedTx.ID()) {
+		t.Fatalf("Rejected tx should not be in a live vertex")
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx2.id)
+	ta.RecordPoll(votes)
+
+	if vtx2.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if ta.TxInLiveVtx(tx2.ID()) {
+		t.Fatalf("Tx should not be in a live vertex after its vertex was accepted")
+	}
+}
+
+func TestAvalancheSharedTxVotes(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      3,
+			BetaRogue:         3,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	// Each validator votes for a different vertex containing the shared tx, so
+	// the tx should receive both votes.
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	votes.Add(1, vtx1.id)
+	ta.RecordPoll(votes)
+
+	if confidence := ta.ConflictGraph().Confidence(tx0); confidence != 1 {
+		t.Fatalf("Wrong confidence. Expected 1 got %d", confidence)
+	}
+
+	votes = ids.UniqueBag{}
+	votes.Add(0, vtx0.id, vtx1.id)
+	votes.Add(1, vtx0.id)
+	ta.RecordPoll(votes)
+
+	if confidence := ta.ConflictGraph().Confidence(tx0); confidence != 2 {
+		t.Fatalf("Wrong confidence. Expected 2 got %d", confidence)
+	}
+
+	// A single validator voting for both vertices should only be counted once
+	votes = ids.UniqueBag{}
+	votes.Add(0, vtx0.id, vtx1.id)
+	ta.RecordPoll(votes)
+
+	if confidence := ta.ConflictGraph().Confidence(tx0); confidence != 0 {
+		t.Fatalf("Wrong confidence. Expected 0 got %d", confidence)
+	} else if tx0.Status() != choices.Processing {
+		t.Fatalf("Tx should still be processing")
+	}
+}
+
+func TestAvalancheInDegreeOverflow(t *testing.T) {
+	if maxInDegree > math.MaxInt32 {
+		t.Fatalf("maxInDegree should fit in an int on 32-bit builds")
+	}
+
+	defer func(max int) { maxInDegree = max }(maxInDegree)
+	maxInDegree = 2
+
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 3,
+			Alpha:             2,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	newTx := func() *snowstorm.TestTx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+		return tx
+	}
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{newTx()},
+		height:       1,
+		status:       choices.Processing,
+	}
+	ta.Add(vtx0)
+
+	children := []*Vtx{}
+	for i := 0; i < 3; i++ {
+		child := &Vtx{
+			dependencies: []Vertex{vtx0},
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{newTx()},
+			height:       2,
+			status:       choices.Processing,
+		}
+		ta.Add(child)
+		children = append(children, child)
+	}
+
+	votes := ids.UniqueBag{}
+	for i, child := range children {
+		votes.Add(uint(i), child.id)
+	}
+	ta.RecordPoll(votes)
+
+	if vtx0.Status() != choices.Processing {
+		t.Fatalf("Poll exceeding the maximum in-degree should have been dropped")
+	}
+
+	maxInDegree = 3
+	ta.RecordPoll(votes)
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Poll within the maximum in-degree should have been applied")
+	}
+}
+
+func TestAvalancheRemoveVtx(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           registry,
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	newTx := func() *snowstorm.TestTx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+		return tx
+	}
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{newTx()},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: []Vertex{vtx0},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{newTx()},
+		height:       2,
+		status:       choices.Processing,
+	}
+	vtx2 := &Vtx{
+		dependencies: vts[:1],
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{newTx()},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	if ta.RemoveVtx(GenerateID()) {
+		t.Fatalf("Shouldn't have removed an unknown vertex")
+	} else if !ta.RemoveVtx(vtx0.id) {
+		t.Fatalf("Should have removed the live vertex")
+	} else if ta.RemoveVtx(vtx0.id) {
+		t.Fatalf("Shouldn't have removed an already removed vertex")
+	}
+
+	if ta.VertexIssued(vtx0) {
+		t.Fatalf("Removed vertex shouldn't be issued")
+	} else if ta.VertexIssued(vtx1) {
+		t.Fatalf("Descendant of a removed vertex shouldn't be issued")
+	} else if !ta.VertexIssued(vtx2) {
+		t.Fatalf("Unrelated vertex should still be issued")
+	} else if vtx0.Status() != choices.Processing || vtx1.Status() != choices.Processing {
+		t.Fatalf("Removed vertices shouldn't have been decided")
+	} else if processing := metricValue(t, registry, "vtx_processing"); processing != 1 {
+		t.Fatalf("Wrong number of processing vertices. Expected 1 got %f", processing)
+	}
+
+	// The txs of the removed vertices can't be voted on anymore
+	virtuous := ta.cg.Virtuous()
+	for _, vtx := range []*Vtx{vtx0, vtx1} {
+		tx := vtx.txs[0]
+		if ta.TxInLiveVtx(tx.ID()) {
+			t.Fatalf("Tx of a removed vertex shouldn't be in a live vertex")
+		} else if tx.Status() != choices.Rejected {
+			t.Fatalf("Tx of a removed vertex should have been rejected")
+		} else if ta.Conflicts(tx).Len() != 0 || virtuous.Contains(tx.ID()) {
+			t.Fatalf("Tx of a removed vertex should have been removed from the conflict graph")
+		}
+	}
+	if ta.Quiesce() {
+		t.Fatalf("Shouldn't quiesce with a virtuous tx in a live vertex")
+	}
+
+	prefs := ta.Preferences()
+	if prefs.Len() != 2 {
+		t.Fatalf("Wrong number of preferences. Expected 2 got %d", prefs.Len())
+	} else if !prefs.Contains(vtx2.id) {
+		t.Fatalf("Live vertex should be preferred")
+	} else if !prefs.Contains(vts[1].ID()) {
+		t.Fatalf("Accepted vertex without live children should be preferred")
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx2.id)
+	ta.RecordPoll(votes)
+
+	if vtx2.Status() != choices.Accepted {
+		t.Fatalf("Remaining vertex should have been accepted")
+	} else if !ta.Quiesce() {
+		t.Fatalf("Should quiesce once the remaining vertex is accepted")
+	} else if !ta.Finalized() {
+		t.Fatalf("Should be finalized once the remaining vertex is accepted")
+	}
+}
+
+func TestAvalancheMaxLiveVertices(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:         2,
+		BatchSize:       1,
+		MaxLiveVertices: 2,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	newVtx := func() *Vtx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+		return &Vtx{
+			dependencies: vts,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       1,
+			status:       choices.Processing,
+		}
+	}
+	vtx0 := newVtx()
+	vtx1 := newVtx()
+	vtx2 := newVtx()
+
+	if err := ta.AddChecked(vtx0); err != nil {
+		t.Fatal(err)
+	} else if err := ta.AddChecked(vtx1); err != nil {
+		t.Fatal(err)
+	} else if err := ta.AddChecked(vtx2); err != ErrLiveSetFull {
+		t.Fatalf("Should have errored with %s, errored with %v", ErrLiveSetFull, err)
+	} else if ta.VertexIssued(vtx2) {
+		t.Fatalf("Vertex shouldn't have been added to a full live set")
+	} else if err := ta.AddChecked(vtx1); err != nil {
+		t.Fatalf("Re-adding a live vertex shouldn't count against the limit: %s", err)
+	} else if err := ta.AddChecked(vts[0]); err != nil {
+		t.Fatalf("Adding a decided vertex shouldn't count against the limit: %s", err)
+	}
+
+	ta.Add(vtx2)
+	if ta.VertexIssued(vtx2) {
+		t.Fatalf("Vertex should have been dropped from a full live set")
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	ta.RecordPoll(votes)
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if err := ta.AddChecked(vtx2); err != nil {
+		t.Fatalf("Vertex should have been added after the live set shrank: %s", err)
+	} else if !ta.VertexIssued(vtx2) {
+		t.Fatalf("Vertex should have been issued")
+	}
+}
+
+func TestAvalancheAncestors(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	newVtx := func(height int, parents ...Vertex) *Vtx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+		return &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       height,
+			status:       choices.Processing,
+		}
+	}
+
+	// Diamond: vtx0 <- {vtx1, vtx2} <- vtx3
+	vtx0 := newVtx(1, vts...)
+	vtx1 := newVtx(2, vtx0)
+	vtx2 := newVtx(2, vtx0)
+	vtx3 := newVtx(3, vtx1, vtx2)
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+	ta.Add(vtx3)
+
+	if ancestors := ta.Ancestors(vtx3.id, 0); len(ancestors) != 0 {
+		t.Fatalf("Depth 0 should return no ancestors, returned %d", len(ancestors))
+	}
+
+	ancestors := ids.Set{}
+	ancestors.Add(ta.Ancestors(vtx3.id, 1)...)
+	expected := ids.Set{}
+	expected.Add(vtx1.id, vtx2.id)
+	if !ancestors.Equals(expected) {
+		t.Fatalf("Wrong ancestors. Expected %s got %s", expected, ancestors)
+	}
+
+	ancestorList := ta.Ancestors(vtx3.id, 5)
+	if len(ancestorList) != 3 {
+		t.Fatalf("Ancestors should be deduplicated and only include live vertices, returned %d", len(ancestorList))
+	}
+	ancestors.Clear()
+	ancestors.Add(ancestorList...)
+	expected.Add(vtx0.id)
+	if !ancestors.Equals(expected) {
+		t.Fatalf("Wrong ancestors. Expected %s got %s", expected, ancestors)
+	} else if !ancestorList[2].Equals(vtx0.id) {
+		t.Fatalf("Ancestors should be returned in breadth first order")
+	}
+
+	if ancestors := ta.Ancestors(GenerateID(), 5); len(ancestors) != 0 {
+		t.Fatalf("Unknown vertex shouldn't have ancestors")
+	}
+}
+
+func TestAvalancheLastAcceptedTxs(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      2,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0, tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	ta.RecordPoll(votes)
+
+	if accepted := ta.LastAcceptedTxs(); len(accepted) != 0 {
+		t.Fatalf("No txs should have been accepted yet, got %d", len(accepted))
+	}
+
+	ta.RecordPoll(votes)
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	}
+
+	accepted := ids.Set{}
+	accepted.Add(ta.LastAcceptedTxs()...)
+	expected := ids.Set{}
+	expected.Add(tx0.ID(), tx1.ID())
+	if !accepted.Equals(expected) {
+		t.Fatalf("Wrong accepted txs. Expected %s got %s", expected, accepted)
+	}
+
+	ta.RecordPoll(votes)
+
+	if accepted := ta.LastAcceptedTxs(); len(accepted) != 0 {
+		t.Fatalf("Accepted txs should only be reported for the poll they were accepted in")
+	}
+}
+
+func TestAvalancheAddWithResult(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	if accepted := ta.AddWithResult(vtx0); len(accepted) != 0 {
+		t.Fatalf("Vertex with a processing tx shouldn't have been accepted")
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Accepted,
+	}
+	tx1.Ins.Add(GenerateID())
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	accepted := ta.AddWithResult(vtx1)
+	if len(accepted) != 1 || !accepted[0].Equals(vtx1.id) {
+		t.Fatalf("Vertex with accepted dependencies should have been accepted immediately, got %v", accepted)
+	} else if vtx1.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	}
+
+	if accepted := ta.AddWithResult(vtx1); len(accepted) != 0 {
+		t.Fatalf("Re-adding a decided vertex shouldn't report it as accepted")
+	}
+}
+
+func TestAvalanchePreferenceDelta(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      3,
+			BetaRogue:         3,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID()}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxos[0])
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[0])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	if prefs := ta.Preferences(); prefs.Len() != 1 || !prefs.Contains(vtx0.id) {
+		t.Fatalf("The first issued vertex should be preferred")
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx1.id)
+	ta.RecordPoll(votes)
+
+	added, removed := ta.PreferenceDelta()
+	if prefs := ta.Preferences(); prefs.Len() != 1 || !prefs.Contains(vtx1.id) {
+		t.Fatalf("The voted for vertex should be preferred")
+	} else if added.Len() != 1 || !added.Contains(vtx1.id) {
+		t.Fatalf("Wrong added preferences: %s", added)
+	} else if removed.Len() != 1 || !removed.Contains(vtx0.id) {
+		t.Fatalf("Wrong removed preferences: %s", removed)
+	}
+
+	ta.RecordPoll(votes)
+
+	if added, removed := ta.PreferenceDelta(); added.Len() != 0 || removed.Len() != 0 {
+		t.Fatalf("Unchanged preferences shouldn't report a delta, added %s removed %s", added, removed)
+	}
+}
+
+func TestAvalancheMalformedResponses(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	// Validator index 2 can't have been sampled when K = 2
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	votes.Add(2, vtx0.id)
+	ta.RecordPoll(votes)
+
+	if vtx0.Status() != choices.Processing {
+		t.Fatalf("Malformed poll should have been dropped")
+	}
+
+	votes = ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	votes.Add(1, vtx0.id)
+	ta.RecordPoll(votes)
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Well formed poll should have been applied")
+	}
+}
+
+func TestAvalancheRecordPollWithID(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      3,
+			BetaRogue:         3,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+
+	if !ta.RecordPollWithID(5, votes) {
+		t.Fatalf("First delivery of the poll should have been recorded")
+	} else if ta.RecordPollWithID(5, votes) {
+		t.Fatalf("Repeated delivery of the poll should have been ignored")
+	} else if confidence := ta.ConflictGraph().Confidence(tx0); confidence != 1 {
+		t.Fatalf("Confidence should have only advanced once, got %d", confidence)
+	}
+
+	if !ta.RecordPollWithID(6, votes) {
+		t.Fatalf("New poll should have been recorded")
+	} else if confidence := ta.ConflictGraph().Confidence(tx0); confidence != 2 {
+		t.Fatalf("Wrong confidence. Expected 2 got %d", confidence)
+	}
+
+	// Once the poll ID leaves the window, it can be reused
+	for pollID := uint32(100); pollID < 100+pollReplayWindow; pollID++ {
+		ta.recentPollIDs[pollID] = true
+		ta.recentPollOrder = append(ta.recentPollOrder, pollID)
+	}
+	if !ta.RecordPollWithID(7, votes) {
+		t.Fatalf("New poll should have been recorded")
+	} else if !ta.RecordPollWithID(5, votes) {
+		t.Fatalf("Poll ID outside of the window should have been recorded")
+	}
+}
+
+type issueRecorder struct{ issued ids.Set }
+
+func (r *issueRecorder) Issue(_, containerID ids.ID, _ []byte) error {
+	r.issued.Add(containerID)
+	return nil
+}
+
+func TestAvalancheMaxVtxSize(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:    2,
+		BatchSize:  1,
+		MaxVtxSize: 4,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ctx := snow.DefaultContextTest()
+	recorder := &issueRecorder{}
+	if err := ctx.ConsensusDispatcher.RegisterChain(ctx.ChainID, "recorder", recorder); err != nil {
+		t.Fatal(err)
+	}
+
+	ta := Topological{}
+	ta.Initialize(ctx, params, vts)
+
+	newVtx := func(bytes []byte) *Vtx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+		return &Vtx{
+			dependencies: vts,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       1,
+			status:       choices.Processing,
+			bytes:        bytes,
+		}
+	}
+	oversized := newVtx([]byte{0, 1, 2, 3, 4})
+	sized := newVtx([]byte{0, 1, 2, 3})
+
+	if err := ta.AddChecked(oversized); err == nil {
+		t.Fatalf("Should have errored on an oversized vertex")
+	}
+	ta.Add(oversized)
+
+	if ta.VertexIssued(oversized) {
+		t.Fatalf("Oversized vertex should have been dropped")
+	} else if recorder.issued.Contains(oversized.id) {
+		t.Fatalf("Oversized vertex shouldn't have been dispatched")
+	}
+
+	ta.Add(sized)
+
+	if !ta.VertexIssued(sized) {
+		t.Fatalf("Vertex within the size limit should have been added")
+	} else if !recorder.issued.Contains(sized.id) {
+		t.Fatalf("Vertex within the size limit should have been dispatched")
+	}
+}
+
+func TestAvalancheAcceptRate(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	now := time.Unix(1000, 0)
+	ta.clock.Set(now)
+
+	if rate := ta.AcceptRate(); rate != 0 {
+		t.Fatalf("Accept rate should be 0 before any accepts, got %f", rate)
+	}
+
+	// Accept one vertex every second for 5 seconds
+	parents := vts
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Second)
+		ta.clock.Set(now)
+
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+
+		vtx := &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       i + 1,
+			status:       choices.Processing,
+		}
+		ta.Add(vtx)
+
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx.id)
+		ta.RecordPoll(votes)
+
+		if vtx.Status() != choices.Accepted {
+			t.Fatalf("Vertex should have been accepted")
+		}
+		parents = []Vertex{vtx}
+	}
+
+	expected := 5 / acceptRateWindow.Seconds()
+	if rate := ta.AcceptRate(); rate != expected {
+		t.Fatalf("Wrong accept rate. Expected %f got %f", expected, rate)
+	}
+
+	// Once the accepts fall outside of the window, they no longer count
+	ta.clock.Set(now.Add(acceptRateWindow - 2*time.Second))
+	expected = 2 / acceptRateWindow.Seconds()
+	if rate := ta.AcceptRate(); rate != expected {
+		t.Fatalf("Wrong accept rate. Expected %f got %f", expected, rate)
+	}
+
+	ta.clock.Set(now.Add(acceptRateWindow))
+	if rate := ta.AcceptRate(); rate != 0 {
+		t.Fatalf("Accept rate should be 0 after the window passes, got %f", rate)
+	}
+}
+
+func TestAvalancheWouldChangePreference(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      3,
+			BetaRogue:         5,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID(), GenerateID()}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxos[0])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[1])
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(utxos[1])
+
+	vtx2 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	// vtx3 is uncontested itself, but votes for it are applied to vtx2
+	tx3 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx3.Ins.Add(GenerateID())
+
+	vtx3 := &Vtx{
+		dependencies: []Vertex{vtx2},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx3},
+		height:       2,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+	ta.Add(vtx3)
+
+	if ta.WouldChangePreference(vts[0].ID()) {
+		t.Fatalf("Votes for an accepted vertex can't change preferences")
+	} else if ta.WouldChangePreference(vtx0.id) {
+		t.Fatalf("Votes for an uncontested vertex can't change preferences")
+	} else if ta.WouldChangePreference(vtx1.id) {
+		t.Fatalf("Votes for an already preferred vertex can't change preferences")
+	} else if !ta.WouldChangePreference(vtx2.id) {
+		t.Fatalf("Votes for a contested vertex that isn't preferred could change preferences")
+	} else if !ta.WouldChangePreference(vtx3.id) {
+		t.Fatalf("Votes for a descendant of a contested vertex could change preferences")
+	}
+}
+
+func TestAvalancheVtxFinalized(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:           2,
+		BatchSize:         1,
+		DecisionRetention: time.Minute,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxo := GenerateID()
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxo)
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxo)
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	if !ta.VtxFinalized(vts[0].ID()) {
+		t.Fatalf("Accepted frontier vertex should be finalized")
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	if ta.VtxFinalized(vtx0.id) {
+		t.Fatalf("Live vertex shouldn't be finalized")
+	} else if ta.VtxFinalized(vtx1.id) {
+		t.Fatalf("Live vertex shouldn't be finalized")
+	} else if ta.VtxFinalized(GenerateID()) {
+		t.Fatalf("Unknown vertex shouldn't be finalized")
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	ta.RecordPoll(votes)
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if vtx1.Status() != choices.Rejected {
+		t.Fatalf("Vertex should have been rejected")
+	} else if !ta.VtxFinalized(vtx0.id) {
+		t.Fatalf("Accepted vertex should be finalized")
+	} else if !ta.VtxFinalized(vtx1.id) {
+		t.Fatalf("Rejected vertex should be finalized")
+	}
+}
+
+func TestAvalancheRecordWeightedPoll(t *testing.T) {
+	newInstance := func(voteDecay float64) (*Topological, *snowstorm.TestTx, *Vtx) {
+		params := Parameters{
+			Parameters: snowball.Parameters{
+				Metrics:           prometheus.NewRegistry(),
+				K:                 1,
+				Alpha:             1,
+				BetaVirtuous:      4,
+				BetaRogue:         4,
+				ConcurrentRepolls: 1,
+			},
+			Parents:   2,
+			BatchSize: 1,
+			VoteDecay: voteDecay,
+		}
+		vts := []Vertex{&Vtx{
+			id:     GenerateID(),
+			status: choices.Accepted,
+		}, &Vtx{
+			id:     GenerateID(),
+			status: choices.Accepted,
+		}}
+
+		ta := &Topological{}
+		ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+
+		vtx := &Vtx{
+			dependencies: vts,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       1,
+			status:       choices.Processing,
+		}
+		ta.Add(vtx)
+		return ta, tx, vtx
+	}
+
+	undecayed, undecayedTx, undecayedVtx := newInstance(0)
+	decayed, decayedTx, decayedVtx := newInstance(.5)
+
+	for i := 1; i <= 2; i++ {
+		votes := ids.UniqueBag{}
+		votes.Add(0, undecayedVtx.id)
+		undecayed.RecordWeightedPoll(votes)
+
+		votes = ids.UniqueBag{}
+		votes.Add(0, decayedVtx.id)
+		decayed.RecordWeightedPoll(votes)
+
+		if confidence := undecayed.ConflictGraph().Confidence(undecayedTx); confidence != i {
+			t.Fatalf("Wrong confidence without decay. Expected %d got %d", i, confidence)
+		}
+		if i == 1 {
+			if confidence := decayed.ConflictGraph().Confidence(decayedTx); confidence != 2 {
+				t.Fatalf("Wrong confidence with decay. Expected %d got %d", 2, confidence)
+			}
+		}
+	}
+
+	if undecayedTx.Status() != choices.Processing {
+		t.Fatalf("Tx shouldn't have been accepted without decay")
+	} else if decayedTx.Status() != choices.Accepted {
+		t.Fatalf("Tx should have been accepted with decay")
+	} else if decayedVtx.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted with decay")
+	}
+}
+
+func TestAvalancheRecordWeightedPollCapped(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      4,
+			BetaRogue:         4,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+		// Each poll would contribute 10 to the confidence without the cap
+		VoteDecay: .1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx.Ins.Add(GenerateID())
+
+	vtx := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx},
+		height:       1,
+		status:       choices.Processing,
+	}
+	ta.Add(vtx)
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx.id)
+	ta.RecordWeightedPoll(votes)
+
+	if confidence := ta.ConflictGraph().Confidence(tx); confidence != 3 {
+		t.Fatalf("Wrong confidence. Expected %d got %d", 3, confidence)
+	} else if tx.Status() != choices.Processing {
+		t.Fatalf("A single poll shouldn't have accepted the tx")
+	}
+
+	ta.RecordWeightedPoll(votes)
+
+	if tx.Status() != choices.Accepted {
+		t.Fatalf("Tx should have been accepted")
+	} else if vtx.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	}
+}
+
+func TestAvalancheProbablyAccepted(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		AcceptedFilterSize: 1024,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	if ta.ProbablyAccepted(GenerateID()) {
+		t.Fatalf("Nothing has been accepted yet")
+	}
+
+	accepted := []ids.ID(nil)
+	parents := vts
+	for i := 0; i < 32; i++ {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+
+		vtx := &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       i + 1,
+			status:       choices.Processing,
+		}
+		ta.Add(vtx)
+
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx.id)
+		ta.RecordPoll(votes)
+
+		if vtx.Status() != choices.Accepted {
+			t.Fatalf("Vertex should have been accepted")
+		}
+		accepted = append(accepted, vtx.id)
+		parents = []Vertex{vtx}
+	}
+
+	for _, vtxID := range accepted {
+		if !ta.ProbablyAccepted(vtxID) {
+			t.Fatalf("Accepted vertex %s should be reported as probably accepted", vtxID)
+		}
+	}
+}
+
+func TestAvalancheVtxStatus(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:           2,
+		BatchSize:         1,
+		DecisionRetention: time.Minute,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxo := GenerateID()
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxo)
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxo)
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	if status := ta.VtxStatus(vts[0].ID()); status != choices.Accepted {
+		t.Fatalf("Accepted frontier vertex should be %s, got %s", choices.Accepted, status)
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	if status := ta.VtxStatus(vtx0.id); status != choices.Processing {
+		t.Fatalf("Live vertex should be %s, got %s", choices.Processing, status)
+	} else if status := ta.VtxStatus(GenerateID()); status != choices.Unknown {
+		t.Fatalf("Unknown vertex should be %s, got %s", choices.Unknown, status)
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	ta.RecordPoll(votes)
+
+	if status := ta.VtxStatus(vtx0.id); status != choices.Accepted {
+		t.Fatalf("Accepted vertex should be %s, got %s", choices.Accepted, status)
+	} else if status := ta.VtxStatus(vtx1.id); status != choices.Rejected {
+		t.Fatalf("Rejected vertex should be %s, got %s", choices.Rejected, status)
+	}
+}
+
+func TestAvalancheFilterResponses(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	unknownID := GenerateID()
+	responses := ids.UniqueBag{}
+	responses.Add(0, vtx0.id, unknownID, vts[0].ID())
+	responses.Add(1, vtx0.id)
+
+	filtered := ta.FilterResponses(responses)
+
+	if !ids.UnsortedEquals([]ids.ID{vtx0.id}, filtered.List()) {
+		t.Fatalf("Only the live vertex should have survived, got %s", filtered.List())
+	} else if voters := filtered.GetSet(vtx0.id); voters.Len() != 2 {
+		t.Fatalf("Expected %d voters for the live vertex, got %d", 2, voters.Len())
+	} else if len(responses) != 3 {
+		t.Fatalf("The responses shouldn't have been modified")
+	}
+
+	ta.RecordPoll(filtered)
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	}
+}
+
+type acceptRecorder struct{ accepted []ids.ID }
+
+func (r *acceptRecorder) Accept(_, containerID ids.ID, _ []byte) error {
+	r.accepted = append(r.accepted, containerID)
+	return nil
+}
+
+func TestAvalancheVirtuousFirst(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:       2,
+		BatchSize:     1,
+		VirtuousFirst: true,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ctx := snow.DefaultContextTest()
+	recorder := &acceptRecorder{}
+	if err := ctx.ConsensusDispatcher.RegisterChain(ctx.ChainID, "recorder", recorder); err != nil {
+		t.Fatal(err)
+	}
+
+	ta := Topological{}
+	ta.Initialize(ctx, params, vts)
+
+	newVtx := func(utxo ids.ID) *Vtx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(utxo)
+		return &Vtx{
+			dependencies: vts,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       1,
+			status:       choices.Processing,
+		}
+	}
+
+	virtuous := ids.Set{}
+	rogue := ids.Set{}
+	conflicting := []*Vtx(nil)
+	votes := ids.UniqueBag{}
+	for i := 0; i < 4; i++ {
+		virtuousVtx := newVtx(GenerateID())
+		ta.Add(virtuousVtx)
+		virtuous.Add(virtuousVtx.id)
+		votes.Add(0, virtuousVtx.id)
+
+		utxo := GenerateID()
+		rogueVtx := newVtx(utxo)
+		conflictingVtx := newVtx(utxo)
+		ta.Add(rogueVtx)
+		ta.Add(conflictingVtx)
+		rogue.Add(rogueVtx.id)
+		conflicting = append(conflicting, conflictingVtx)
+		votes.Add(0, rogueVtx.id)
+	}
+
+	ta.RecordPoll(votes)
+
+	if len(recorder.accepted) != virtuous.Len()+rogue.Len() {
+		t.Fatalf("Expected %d accepted vertices, got %d", virtuous.Len()+rogue.Len(), len(recorder.accepted))
+	}
+	for i, vtxID := range recorder.accepted {
+		if i < virtuous.Len() && !virtuous.Contains(vtxID) {
+			t.Fatalf("Expected a virtuous vertex to be accepted at index %d, got %s", i, vtxID)
+		} else if i >= virtuous.Len() && !rogue.Contains(vtxID) {
+			t.Fatalf("Expected a rogue vertex to be accepted at index %d, got %s", i, vtxID)
+		}
+	}
+	for _, vtx := range conflicting {
+		if vtx.Status() != choices.Rejected {
+			t.Fatalf("Conflicting vertex should have been rejected")
+		}
+	}
+}
+
+func TestAvalancheBuildableSet(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      3,
+			BetaRogue:         3,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxo := GenerateID()
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	newVtx := func(utxo ids.ID) *Vtx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(utxo)
+		return &Vtx{
+			dependencies: vts,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       1,
+			status:       choices.Processing,
+		}
+	}
+
+	vtx0 := newVtx(utxo)
+	vtx1 := newVtx(utxo)
+	vtx2 := newVtx(GenerateID())
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	if buildable := ta.BuildableSet(); !ids.UnsortedEquals([]ids.ID{vtx0.id, vtx2.id}, buildable) {
+		t.Fatalf("Wrong buildable set, got %s", buildable)
+	}
+
+	// Conflicting vertices can't both be preferred through voting, so the
+	// preference is forced to check that the conflict is excluded
+	ta.preferred.Add(vtx1.id)
+
+	buildable := ta.BuildableSet()
+	if len(buildable) != 2 {
+		t.Fatalf("Expected %d buildable vertices, got %d", 2, len(buildable))
+	} else if !ids.UnsortedEquals(buildable, []ids.ID{vtx0.id, vtx2.id}) &&
+		!ids.UnsortedEquals(buildable, []ids.ID{vtx1.id, vtx2.id}) {
+		t.Fatalf("Only one of the conflicting vertices should be buildable, got %s", buildable)
+	}
+}
+
+type countingCG struct {
+	snowstorm.Consensus
+	preferencesCalls, virtuousCalls int
+}
+
+func (cg *countingCG) Preferences() ids.Set {
+	cg.preferencesCalls++
+	return cg.Consensus.Preferences()
+}
+
+func (cg *countingCG) Virtuous() ids.Set {
+	cg.virtuousCalls++
+	return cg.Consensus.Virtuous()
+}
+
+func TestAvalancheMemoizedConflictGraphSets(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      2,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	cg := &countingCG{Consensus: ta.cg}
+	ta.cg = cg
+	ta.cgSetsCached = false
+
+	parents := vts
+	for i := 0; i < 5; i++ {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+
+		vtx := &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       i + 1,
+			status:       choices.Processing,
+		}
+		ta.Add(vtx)
+		parents = []Vertex{vtx}
+	}
+
+	for i := 0; i < 2; i++ {
+		cg.preferencesCalls, cg.virtuousCalls = 0, 0
+
+		votes := ids.UniqueBag{}
+		votes.Add(0, parents[0].ID())
+		ta.RecordPoll(votes)
+
+		if cg.preferencesCalls != 1 {
+			t.Fatalf("Poll %d called Preferences %d times, expected %d", i, cg.preferencesCalls, 1)
+		} else if cg.virtuousCalls != 1 {
+			t.Fatalf("Poll %d called Virtuous %d times, expected %d", i, cg.virtuousCalls, 1)
+		}
+	}
+
+	if !ta.Finalized() {
+		t.Fatalf("All the vertices should have been accepted")
+	}
+}
+
+func TestAvalancheInitializeWithAccepted(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		DecisionRetention:  time.Minute,
+		AcceptedFilterSize: 1024,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	accepted := []ids.ID{GenerateID(), GenerateID(), GenerateID()}
+
+	ta := Topological{}
+	if err := ta.InitializeWithAccepted(snow.DefaultContextTest(), params, vts, accepted); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vtxID := range accepted {
+		if !ta.ProbablyAccepted(vtxID) {
+			t.Fatalf("Pre-accepted vertex %s should be reported as probably accepted", vtxID)
+		} else if status := ta.VtxStatus(vtxID); status != choices.Accepted {
+			t.Fatalf("Pre-accepted vertex %s should be %s, got %s", vtxID, choices.Accepted, status)
+		}
+	}
+
+	if !ids.UnsortedEquals([]ids.ID{vts[0].ID(), vts[1].ID()}, ta.Preferences().List()) {
+		t.Fatalf("Initial frontier failed to be set")
+	}
+}
+
+func TestAvalancheNilParent(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      2,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: []Vertex{vts[0], nil, vts[1]},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(GenerateID())
+
+	vtx1 := &Vtx{
+		dependencies: []Vertex{nil, vtx0},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       2,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	if !ids.UnsortedEquals([]ids.ID{vtx1.id}, ta.Preferences().List()) {
+		t.Fatalf("Vertex with a nil parent should be preferred")
+	} else if ancestors := ta.Ancestors(vtx1.id, 2); !ids.UnsortedEquals([]ids.ID{vtx0.id}, ancestors) {
+		t.Fatalf("Wrong ancestors, got %s", ancestors)
+	}
+
+	for i := 0; i < 2; i++ {
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx1.id)
+		ta.RecordPoll(votes)
+	}
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex with a nil parent should have been accepted")
+	} else if vtx1.Status() != choices.Accepted {
+		t.Fatalf("Vertex with a nil parent should have been accepted")
+	} else if !ta.Finalized() {
+		t.Fatalf("An avalanche instance should have finalized")
+	}
+}
+
+func TestAvalancheAcceptanceSequence(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	newVtx := func(parents []Vertex, height int) *Vtx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+		return &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       height,
+			status:       choices.Processing,
+		}
+	}
+
+	sequences := []uint64(nil)
+	params.OnAccept = func(_ ids.ID, _ []byte, sequence uint64) { sequences = append(sequences, sequence) }
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	if sequence := ta.AcceptanceSequence(); sequence != 0 {
+		t.Fatalf("Sequence should start at 0, got %d", sequence)
+	}
+
+	parents := vts
+	for i := 1; i <= 3; i++ {
+		vtx := newVtx(parents, i)
+		ta.Add(vtx)
+
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx.id)
+		ta.RecordPoll(votes)
+
+		if vtx.Status() != choices.Accepted {
+			t.Fatalf("Vertex should have been accepted")
+		} else if sequence := ta.AcceptanceSequence(); sequence != uint64(i) {
+			t.Fatalf("Wrong acceptance sequence. Expected %d got %d", i, sequence)
+		}
+		parents = []Vertex{vtx}
+	}
+
+	b, err := ta.Snapshot().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := Snapshot{}
+	if err := snapshot.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if snapshot.AcceptanceSequence != 3 {
+		t.Fatalf("Snapshot has acceptance sequence %d, expected %d", snapshot.AcceptanceSequence, 3)
+	}
+
+	// Restart from the snapshot
+	params.Metrics = prometheus.NewRegistry()
+	restarted := Topological{}
+	restarted.Initialize(snow.DefaultContextTest(), params, parents)
+	restarted.ResumeAcceptanceSequence(snapshot.AcceptanceSequence)
+
+	vtx := newVtx(parents, 4)
+	restarted.Add(vtx)
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx.id)
+	restarted.RecordPoll(votes)
+
+	if expected := []uint64{1, 2, 3, 4}; !reflect.DeepEqual(sequences, expected) {
+		t.Fatalf("Wrong acceptance sequences. Expected %v got %v", expected, sequences)
+	}
+}
+
+func TestAvalancheDecisionCallbacks(t *testing.T) {
+	accepted := []ids.ID(nil)
+	acceptedBytes := [][]byte(nil)
+	rejected := []ids.ID(nil)
+	rejectedBytes := [][]byte(nil)
+
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+		OnAccept: func(vtxID ids.ID, bytes []byte, _ uint64) {
+			accepted = append(accepted, vtxID)
+			acceptedBytes = append(acceptedBytes, bytes)
+		},
+		OnReject: func(vtxID ids.ID, bytes []byte, _ string) {
+			rejected = append(rejected, vtxID)
+			rejectedBytes = append(rejectedBytes, bytes)
+		},
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	utxo := GenerateID()
+
+	// Build a chain of vertices, where the first vertex conflicts with a
+	// vertex outside of the chain
+	chain := []*Vtx(nil)
+	parents := vts
+	for i := 0; i < 4; i++ {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		if i == 0 {
+			tx.Ins.Add(utxo)
+		} else {
+			tx.Ins.Add(GenerateID())
+		}
+
+		vtx := &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       i + 1,
+			status:       choices.Processing,
+			bytes:        []byte{byte(i)},
+		}
+		ta.Add(vtx)
+
+		chain = append(chain, vtx)
+		parents = []Vertex{vtx}
+	}
+
+	conflictTx := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	conflictTx.Ins.Add(utxo)
+
+	conflict := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{conflictTx},
+		height:       1,
+		status:       choices.Processing,
+		bytes:        []byte{byte(len(chain))},
+	}
+	ta.Add(conflict)
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, chain[len(chain)-1].id)
+	ta.RecordPoll(votes)
+
+	if len(accepted) != len(chain) {
+		t.Fatalf("Expected %d accepted vertices, got %d", len(chain), len(accepted))
+	}
+	for i, vtx := range chain {
+		if !accepted[i].Equals(vtx.id) {
+			t.Fatalf("Vertex %d of the chain was accepted out of order", i)
+		} else if !bytes.Equal(acceptedBytes[i], vtx.bytes) {
+			t.Fatalf("Wrong bytes for vertex %d. Expected %v got %v", i, vtx.bytes, acceptedBytes[i])
+		}
+	}
+
+	if len(rejected) != 1 {
+		t.Fatalf("Expected %d rejected vertex, got %d", 1, len(rejected))
+	} else if !rejected[0].Equals(conflict.id) {
+		t.Fatalf("Wrong vertex rejected")
+	} else if !bytes.Equal(rejectedBytes[0], conflict.bytes) {
+		t.Fatalf("Wrong bytes for the rejected vertex. Expected %v got %v", conflict.bytes, rejectedBytes[0])
+	}
+}
+
+func TestAvalancheVerifyFrontiers(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:         2,
+		BatchSize:       1,
+		VerifyFrontiers: true,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxo := GenerateID()
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	newVtx := func(parents []Vertex, utxo ids.ID, height int) *Vtx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(utxo)
+		return &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       height,
+			status:       choices.Processing,
+		}
+	}
+
+	vtx0 := newVtx(vts, utxo, 1)
+	vtx1 := newVtx(vts, utxo, 1)
+	vtx2 := newVtx([]Vertex{vtx0}, GenerateID(), 2)
+	vtx3 := newVtx(vts, GenerateID(), 1)
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+	ta.Add(vtx3)
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx3.id)
+	ta.RecordPoll(votes)
+
+	if vtx3.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if err := ta.verifyFrontiers(); err != nil {
+		t.Fatalf("Frontiers should have been consistent after a poll: %s", err)
+	}
+
+	// Simulate an incremental update that lost track of a live leaf
+	delete(ta.frontier, vtx2.id.Key())
+	ta.preferred.Remove(vtx2.id)
+
+	if err := ta.verifyFrontiers(); err == nil {
+		t.Fatalf("Should have reported the inconsistent frontiers")
+	} else if err := ta.verifyFrontiers(); err != nil {
+		t.Fatalf("The recomputed frontiers should have been kept: %s", err)
+	}
+}
+
+func TestAvalancheRejectedChildRestoresParent(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxo := GenerateID()
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	newVtx := func(parents []Vertex, utxo ids.ID, height int) *Vtx {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(utxo)
+		return &Vtx{
+			dependencies: parents,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       height,
+			status:       choices.Processing,
+		}
+	}
+
+	vtx0 := newVtx(vts, GenerateID(), 1)
+	vtx1 := newVtx([]Vertex{vtx0}, utxo, 2)
+	vtx2 := newVtx(vts, utxo, 1)
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	for i := 0; i < params.BetaRogue; i++ {
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx2.id)
+		ta.RecordPoll(votes)
+	}
+
+	if vtx2.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if vtx1.Status() != choices.Rejected {
+		t.Fatalf("Vertex should have been rejected")
+	} else if vtx0.Status() != choices.Processing {
+		t.Fatalf("Vertex should have been processing")
+	}
+
+	// The parent of the rejected vertex must still be updated
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	ta.RecordPoll(votes)
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	}
+}
+
+func TestAvalanchePendingWork(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      3,
+			BetaRogue:         5,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+	utxos := []ids.ID{GenerateID(), GenerateID()}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	if pending := ta.PendingWork(); pending != 0 {
+		t.Fatalf("Expected no pending work, got %d", pending)
+	}
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxos[0])
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxos[0])
+
+	// tx2 can't be accepted until tx0 is accepted
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Deps:       []snowstorm.Tx{tx0},
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(utxos[1])
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx2 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	if pending := ta.PendingWork(); pending != 3 {
+		t.Fatalf("Expected %d pending txs, got %d", 3, pending)
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx2.id)
+	for i := 0; i < 2; i++ {
+		ta.RecordPoll(votes)
+	}
+
+	if pending := ta.PendingWork(); pending != 3 {
+		t.Fatalf("Tx below its beta confidence should still be pending, got %d pending txs", pending)
+	}
+
+	ta.RecordPoll(votes)
+
+	if tx2.Status() != choices.Processing {
+		t.Fatalf("Tx with a processing dependency shouldn't be accepted")
+	} else if pending := ta.PendingWork(); pending != 2 {
+		t.Fatalf("Tx at its beta confidence shouldn't be pending, got %d pending txs", pending)
+	}
+}
+
+func TestAvalancheNilTx(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0, nil},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	if err := ta.AddChecked(vtx0); err == nil {
+		t.Fatalf("Should have errored on a vertex with a nil tx")
+	}
+	ta.Add(vtx0)
+
+	if ta.VertexIssued(vtx0) {
+		t.Fatalf("Vertex with a nil tx should have been dropped")
+	} else if ta.TxIssued(tx0) {
+		t.Fatalf("Tx of a dropped vertex shouldn't have been issued")
+	} else if !ta.Finalized() {
+		t.Fatalf("An avalanche instance without live vertices should be finalized")
+	}
+}
+
+func TestAvalancheDuplicateTx(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0, tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	if err := ta.AddChecked(vtx0); err == nil {
+		t.Fatalf("Should have errored on a vertex with a duplicated tx")
+	} else if ta.VertexIssued(vtx0) {
+		t.Fatalf("Vertex with a duplicated tx should have been dropped")
+	} else if ta.TxIssued(tx0) {
+		t.Fatalf("Tx of a dropped vertex shouldn't have been issued")
+	}
+
+	// The same tx is accepted once it is only issued once
+	if err := ta.AddChecked(vtx1); err != nil {
+		t.Fatal(err)
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx1.id)
+	ta.RecordPoll(votes)
+
+	if tx0.Status() != choices.Accepted {
+		t.Fatalf("Tx should have been accepted")
+	} else if vtx1.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	}
+}
+
+func TestAvalancheProcessing(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	if processing := ta.Processing(); len(processing) != 0 {
+		t.Fatalf("Expected no processing vertices, got %d", len(processing))
+	} else if numProcessing := ta.NumProcessing(); numProcessing != 0 {
+		t.Fatalf("Expected no processing vertices, got %d", numProcessing)
+	}
+
+	utxo := GenerateID()
+	added := []*Vtx(nil)
+	for i := 0; i < 5; i++ {
+		// Every tx spends the same utxo, so no vertex can be accepted after a
+		// single poll
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(utxo)
+
+		vtx := &Vtx{
+			dependencies: vts,
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       1,
+			status:       choices.Processing,
+		}
+		ta.Add(vtx)
+		added = append(added, vtx)
+	}
+
+	expected := make([]ids.ID, len(added))
+	for i, vtx := range added {
+		expected[i] = vtx.id
+	}
+	ids.SortIDs(expected)
+
+	processing := ta.Processing()
+	if numProcessing := ta.NumProcessing(); numProcessing != len(expected) {
+		t.Fatalf("Expected %d processing vertices, got %d", len(expected), numProcessing)
+	} else if len(processing) != len(expected) {
+		t.Fatalf("Expected %d processing vertices, got %d", len(expected), len(processing))
+	}
+	for i, vtx := range processing {
+		if !vtx.ID().Equals(expected[i]) {
+			t.Fatalf("Expected processing vertex %d to be %s, got %s", i, expected[i], vtx.ID())
+		}
+	}
+
+	// Modifying the returned slice doesn't modify the instance
+	processing[0] = nil
+	if vtx := ta.Processing()[0]; vtx == nil || !vtx.ID().Equals(expected[0]) {
+		t.Fatalf("Processing should return a new slice")
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, added[0].id)
+	ta.RecordPoll(votes)
+	ta.RecordPoll(votes)
+
+	if numProcessing := ta.NumProcessing(); numProcessing != 0 {
+		t.Fatalf("Expected no processing vertices, got %d", numProcessing)
+	} else if processing := ta.Processing(); len(processing) != 0 {
+		t.Fatalf("Expected no processing vertices, got %d", len(processing))
+	}
+}
+
+func TestAvalancheConflicts(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	utxo := GenerateID()
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxo)
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxo)
+
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(GenerateID())
+
+	ta.Add(&Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	})
+	ta.Add(&Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1, tx2},
+		height:       1,
+		status:       choices.Processing,
+	})
+
+	if conflicts := ta.Conflicts(tx0); !ids.UnsortedEquals(conflicts.List(), []ids.ID{tx1.ID()}) {
+		t.Fatalf("Expected conflicts %s, got %s", []ids.ID{tx1.ID()}, conflicts)
+	} else if conflicts := ta.Conflicts(tx1); !ids.UnsortedEquals(conflicts.List(), []ids.ID{tx0.ID()}) {
+		t.Fatalf("Expected conflicts %s, got %s", []ids.ID{tx0.ID()}, conflicts)
+	} else if conflicts := ta.Conflicts(tx2); conflicts.Len() != 0 {
+		t.Fatalf("Virtuous tx shouldn't have conflicts, got %s", conflicts)
+	}
+
+	// A tx that hasn't been issued conflicts with the txs spending its inputs
+	tx3 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx3.Ins.Add(utxo)
+
+	if conflicts := ta.Conflicts(tx3); !ids.UnsortedEquals(conflicts.List(), []ids.ID{tx0.ID(), tx1.ID()}) {
+		t.Fatalf("Expected conflicts %s, got %s", []ids.ID{tx0.ID(), tx1.ID()}, conflicts)
+	}
+}
+
+func TestAvalancheRemoveTx(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	reasons := map[[32]byte]string{}
+	params.OnReject = func(vtxID ids.ID, _ []byte, reason string) { reasons[vtxID.Key()] = reason }
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(GenerateID())
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(GenerateID())
+
+	vtx2 := &Vtx{
+		dependencies: []Vertex{vtx0},
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       2,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	if err := ta.RemoveTx(GenerateID()); err == nil {
+		t.Fatalf("Shouldn't be able to remove a tx that wasn't issued")
+	}
+	if err := ta.RemoveTx(tx0.ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	if tx0.Status() != choices.Rejected {
+		t.Fatalf("Removed tx should have been rejected")
+	} else if vtx0.Status() != choices.Rejected {
+		t.Fatalf("Vertex containing the removed tx should have been rejected")
+	} else if vtx2.Status() != choices.Rejected {
+		t.Fatalf("Descendent of the rejected vertex should have been rejected")
+	} else if reason := reasons[vtx0.id.Key()]; reason != RejectReasonRejectedTx {
+		t.Fatalf("Expected rejection reason %q, got %q", RejectReasonRejectedTx, reason)
+	} else if prefs := ta.Preferences(); prefs.Len() != 1 || !prefs.Contains(vtx1.id) {
+		t.Fatalf("Wrong preferences. Expected %s got %s", vtx1.id, prefs)
+	} else if virtuous := ta.Virtuous(); virtuous.Len() != 1 || !virtuous.Contains(vtx1.id) {
+		t.Fatalf("Wrong virtuous. Expected %s got %s", vtx1.id, virtuous)
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id, vtx1.id)
+	ta.RecordPoll(votes)
+
+	if vtx1.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if tx1.Status() != choices.Accepted {
+		t.Fatalf("Tx should have been accepted")
+	}
+}
+
+func TestAvalancheRecordPollResult(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 2,
+			Alpha:             2,
+			BetaVirtuous:      1,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	utxo := GenerateID()
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxo)
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxo)
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	tx2 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx2.Ins.Add(GenerateID())
+
+	vtx2 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx2},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+	ta.Add(vtx2)
+
+	// A voter that wasn't sampled causes the poll to be dropped
+	votes := ids.UniqueBag{}
+	votes.Add(2, vtx0.id)
+	if result := ta.RecordPollResult(votes); result != (PollResult{}) {
+		t.Fatalf("Dropped poll should have an empty result, got %+v", result)
+	}
+
+	// Only vtx0 receives alpha votes
+	votes = ids.UniqueBag{}
+	votes.Add(0, vtx0.id, vtx2.id)
+	votes.Add(1, vtx0.id)
+	if result := ta.RecordPollResult(votes); result != (PollResult{GainedConfidence: 1}) {
+		t.Fatalf("Wrong result %+v", result)
+	}
+
+	votes = ids.UniqueBag{}
+	votes.Add(0, vtx0.id, vtx2.id)
+	votes.Add(1, vtx0.id, vtx2.id)
+	if result := ta.RecordPollResult(votes); result != (PollResult{GainedConfidence: 2, Accepted: 2, Rejected: 1}) {
+		t.Fatalf("Wrong result %+v", result)
+	}
+
+	if vtx0.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	} else if vtx1.Status() != choices.Rejected {
+		t.Fatalf("Vertex should have been rejected")
+	} else if vtx2.Status() != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted")
+	}
+}
+
+func TestAvalancheMaxProcessingDepth(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:            2,
+		BatchSize:          1,
+		MaxProcessingDepth: 5,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	// Each vertex of the chain also references a genesis vertex, so the depth
+	// must be measured along the longest path
+	chain := []*Vtx(nil)
+	parent := vts[0]
+	for i := 0; i < 7; i++ {
+		tx := &snowstorm.TestTx{
+			Identifier: GenerateID(),
+			Stat:       choices.Processing,
+		}
+		tx.Ins.Add(GenerateID())
+
+		vtx := &Vtx{
+			dependencies: []Vertex{vts[1], parent},
+			id:           GenerateID(),
+			txs:          []snowstorm.Tx{tx},
+			height:       i + 1,
+			status:       choices.Processing,
+		}
+		chain = append(chain, vtx)
+		parent = vtx
+	}
+
+	for i, vtx := range chain[:5] {
+		if err := ta.AddChecked(vtx); err != nil {
+			t.Fatalf("Vertex %d of the chain should have been added, but errored with %s", i, err)
+		}
+	}
+	ta.Add(chain[5])
+
+	if numProcessing := ta.NumProcessing(); numProcessing != 5 {
+		t.Fatalf("Expected %d processing vertices, got %d", 5, numProcessing)
+	} else if err := ta.AddChecked(chain[5]); err == nil {
+		t.Fatalf("Should have errored on a vertex beyond the max processing depth")
+	}
+
+	// Once the start of the chain is accepted, the chain can be extended
+	votes := ids.UniqueBag{}
+	votes.Add(0, chain[0].id)
+	ta.RecordPoll(votes)
+
+	if status := chain[0].Status(); status != choices.Accepted {
+		t.Fatalf("Vertex should have been accepted, but is %s", status)
+	} else if err := ta.AddChecked(chain[5]); err != nil {
+		t.Fatal(err)
+	} else if err := ta.AddChecked(chain[6]); err == nil {
+		t.Fatalf("Should have errored on a vertex beyond the max processing depth")
+	}
+}
@@ -0,0 +1,52 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+// Tracer opens spans around the phases of recording a poll, so that polls can
+// be followed by a distributed tracing system.
+type Tracer interface {
+	// StartSpan opens a span named [name]. If [parent] is non-nil, the span is
+	// a child of [parent].
+	StartSpan(parent Span, name string) Span
+}
+
+// Span is an operation that is being traced
+type Span interface {
+	// SetAttribute describes the operation with a count, such as the number of
+	// vertices it processed
+	SetAttribute(key string, value int)
+	// End marks the operation as finished
+	End()
+}
+
+// startSpan opens a span if a tracer was provided. Otherwise, nil is returned
+// and nothing is traced.
+func (ta *Topological) startSpan(parent Span, name string) Span {
+	if ta.params.Tracer == nil {
+		return nil
+	}
+	return ta.params.Tracer.StartSpan(parent, name)
+}
+
+func setSpanAttribute(span Span, key string, value int) {
+	if span != nil {
+		span.SetAttribute(key, value)
+	}
+}
+
+func endSpan(span Span) {
+	if span != nil {
+		span.End()
+	}
+}
+
+// tracedUpdateFrontiers updates the frontiers inside of a child span of
+// [parent]
+func (ta *Topological) tracedUpdateFrontiers(parent Span) {
+	span := ta.startSpan(parent, "updateFrontiers")
+	ta.updateFrontiers()
+	setSpanAttribute(span, "frontier", len(ta.frontier))
+	setSpanAttribute(span, "live", len(ta.nodes))
+	endSpan(span)
+}
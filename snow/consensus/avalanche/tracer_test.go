@@ -0,0 +1,135 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+type recordedSpan struct {
+	name       string
+	parent     *recordedSpan
+	attributes map[string]int
+	ended      bool
+}
+
+func (s *recordedSpan) SetAttribute(key string, value int) { s.attributes[key] = value }
+func (s *recordedSpan) End()                               { s.ended = true }
+
+type recordingTracer struct{ spans []*recordedSpan }
+
+func (t *recordingTracer) StartSpan(parent Span, name string) Span {
+	span := &recordedSpan{
+		name:       name,
+		attributes: make(map[string]int),
+	}
+	if parent != nil {
+		span.parent = parent.(*recordedSpan)
+	}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+func TestTracerSpans(t *testing.T) {
+	tracer := &recordingTracer{}
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      1,
+			BetaRogue:         1,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+		Tracer:    tracer,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	utxo := GenerateID()
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(utxo)
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	// The conflict keeps the poll off of the conflictless fast path
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(utxo)
+
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	if len(tracer.spans) != 0 {
+		t.Fatalf("Adding a vertex shouldn't be traced")
+	}
+
+	votes := ids.UniqueBag{}
+	votes.Add(0, vtx0.id)
+	ta.RecordPoll(votes)
+
+	expectedNames := []string{"RecordPoll", "calculateInDegree", "pushVotes", "cg.RecordPoll", "updateFrontiers"}
+	if len(tracer.spans) != len(expectedNames) {
+		t.Fatalf("Expected %d spans, got %d", len(expectedNames), len(tracer.spans))
+	}
+	root := tracer.spans[0]
+	for i, span := range tracer.spans {
+		switch {
+		case span.name != expectedNames[i]:
+			t.Fatalf("Expected span %d to be %s, got %s", i, expectedNames[i], span.name)
+		case !span.ended:
+			t.Fatalf("Span %s wasn't ended", span.name)
+		case i == 0 && span.parent != nil:
+			t.Fatalf("Span %s should be a root span", span.name)
+		case i > 0 && span.parent != root:
+			t.Fatalf("Span %s should be a child of %s", span.name, root.name)
+		}
+	}
+
+	if responses := root.attributes["responses"]; responses != 1 {
+		t.Fatalf("Expected %d responses, got %d", 1, responses)
+	} else if vertices := tracer.spans[1].attributes["vertices"]; vertices != 1 {
+		t.Fatalf("Expected %d vertices, got %d", 1, vertices)
+	} else if numVotes := tracer.spans[2].attributes["votes"]; numVotes != 1 {
+		t.Fatalf("Expected %d votes, got %d", 1, numVotes)
+	}
+}
@@ -0,0 +1,206 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+type randomTxSpec struct {
+	id  ids.ID
+	ins []ids.ID
+}
+
+type randomVtxSpec struct {
+	id ids.ID
+	// Indices of the parents, where the first len(genesis) indices are the
+	// genesis vertices
+	parents []int
+	txs     []randomTxSpec
+}
+
+// randomPollSpec describes a poll where the first validators vote for the
+// preferred frontier if they responded, and the last validator votes for a
+// random vertex. Votes for conflicting transactions reaching alpha in the same
+// poll would make the conflict graph's decisions depend on map iteration order,
+// so the random vote alone never reaches alpha.
+type randomPollSpec struct {
+	responded []bool
+	// Index of the vertex the last validator votes for
+	random int
+}
+
+// randomDAGSpec describes a random sequence of vertices to add, with a poll
+// recorded after each vertex is added
+type randomDAGSpec struct {
+	genesis []ids.ID
+	vts     []randomVtxSpec
+	polls   []randomPollSpec
+}
+
+func newRandomDAGSpec(rng *rand.Rand, numVts, numInputs, k int) randomDAGSpec {
+	newID := func() ids.ID {
+		id := [32]byte{}
+		rng.Read(id[:])
+		return ids.NewID(id)
+	}
+	inputs := make([]ids.ID, numInputs)
+	for i := range inputs {
+		inputs[i] = newID()
+	}
+
+	spec := randomDAGSpec{genesis: []ids.ID{newID(), newID()}}
+	numGenesis := len(spec.genesis)
+	for i := 0; i < numVts; i++ {
+		vtx := randomVtxSpec{id: newID()}
+		for _, parent := range rng.Perm(numGenesis + i)[:1+rng.Intn(2)] {
+			vtx.parents = append(vtx.parents, parent)
+		}
+		for j := 1 + rng.Intn(2); j > 0; j-- {
+			vtx.txs = append(vtx.txs, randomTxSpec{
+				id:  newID(),
+				ins: []ids.ID{inputs[rng.Intn(numInputs)]},
+			})
+		}
+		spec.vts = append(spec.vts, vtx)
+
+		poll := randomPollSpec{
+			responded: make([]bool, k-1),
+			random:    rng.Intn(1 + i),
+		}
+		for j := range poll.responded {
+			poll.responded[j] = rng.Intn(5) != 0
+		}
+		spec.polls = append(spec.polls, poll)
+	}
+	return spec
+}
+
+// run adds the vertices of the spec to a new instance, recording the polls of
+// the spec. [step] is called with the vertices after each poll is recorded.
+func (spec randomDAGSpec) run(t *testing.T, step func(i int, ta *Topological, vts []*Vtx)) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 3,
+			Alpha:             2,
+			BetaVirtuous:      2,
+			BetaRogue:         3,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   2,
+		BatchSize: 1,
+	}
+
+	vts := []*Vtx(nil)
+	genesis := []Vertex(nil)
+	for _, id := range spec.genesis {
+		vtx := &Vtx{
+			id:     id,
+			status: choices.Accepted,
+		}
+		vts = append(vts, vtx)
+		genesis = append(genesis, vtx)
+	}
+
+	ta := &Topological{}
+	if err := ta.Initialize(snow.DefaultContextTest(), params, genesis); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, vtxSpec := range spec.vts {
+		vtx := &Vtx{
+			id:     vtxSpec.id,
+			status: choices.Processing,
+		}
+		for _, parent := range vtxSpec.parents {
+			vtx.dependencies = append(vtx.dependencies, vts[parent])
+			if height := vts[parent].height + 1; height > vtx.height {
+				vtx.height = height
+			}
+		}
+		for _, txSpec := range vtxSpec.txs {
+			tx := &snowstorm.TestTx{
+				Identifier: txSpec.id,
+				Stat:       choices.Processing,
+			}
+			tx.Ins.Add(txSpec.ins...)
+			vtx.txs = append(vtx.txs, tx)
+		}
+		vts = append(vts, vtx)
+		ta.Add(vtx)
+
+		poll := spec.polls[i]
+		votes := ids.UniqueBag{}
+		for validator, responded := range poll.responded {
+			if responded {
+				votes.Add(uint(validator), ta.Preferences().List()...)
+			}
+		}
+		votes.Add(uint(len(poll.responded)), vts[len(spec.genesis)+poll.random].id)
+		ta.RecordPoll(votes)
+
+		step(i, ta, vts)
+	}
+}
+
+// TestUpdateRandomDAGs checks, over many random DAGs, that the frontiers
+// maintained by update match the frontiers recomputed from every live vertex,
+// and that vertices are only accepted after their parents and transactions
+func TestUpdateRandomDAGs(t *testing.T) {
+	accepted, rejected := 0, 0
+	for seed := int64(0); seed < 100; seed++ {
+		spec := newRandomDAGSpec(rand.New(rand.NewSource(seed)), 50, 20, 3)
+
+		final := []*Vtx(nil)
+		spec.run(t, func(i int, ta *Topological, vts []*Vtx) {
+			if err := ta.verifyFrontiers(); err != nil {
+				t.Fatalf("seed %d, step %d: %s", seed, i, err)
+			}
+
+			for j, vtx := range vts {
+				switch vtx.Status() {
+				case choices.Accepted:
+					for _, parent := range vtx.dependencies {
+						if parent.Status() != choices.Accepted {
+							t.Fatalf("seed %d, step %d: vertex %d was accepted before its parent %s", seed, i, j, parent.ID())
+						}
+					}
+					for _, tx := range vtx.txs {
+						if tx.Status() != choices.Accepted {
+							t.Fatalf("seed %d, step %d: vertex %d was accepted with a %s tx", seed, i, j, tx.Status())
+						}
+					}
+				case choices.Rejected:
+					if _, live := ta.nodes[vtx.id.Key()]; live {
+						t.Fatalf("seed %d, step %d: rejected vertex %d is still live", seed, i, j)
+					}
+				}
+			}
+			final = vts
+		})
+
+		for _, vtx := range final[len(spec.genesis):] {
+			switch vtx.Status() {
+			case choices.Accepted:
+				accepted++
+			case choices.Rejected:
+				rejected++
+			}
+		}
+	}
+	t.Logf("accepted %d, rejected %d", accepted, rejected)
+	if accepted == 0 || rejected == 0 {
+		t.Fatalf("The random DAGs should have accepted and rejected vertices")
+	}
+}
@@ -0,0 +1,92 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+// VoteSample is the number of votes a transaction received in a poll
+type VoteSample struct {
+	// Poll is the index of the poll, counting from the first poll recorded by
+	// this instance
+	Poll uint64
+	// Votes is the number of votes the transaction received in the poll
+	Votes int
+}
+
+// voteRing holds the most recent samples of a transaction
+type voteRing struct {
+	samples []VoteSample
+	// Index in samples the next sample will be written to
+	next int
+	// True if the transaction is no longer processing
+	decided bool
+}
+
+func (r *voteRing) add(sample VoteSample, size int) {
+	if len(r.samples) < size {
+		r.samples = append(r.samples, sample)
+		return
+	}
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % size
+}
+
+// list returns the samples, oldest first
+func (r *voteRing) list() []VoteSample {
+	samples := make([]VoteSample, 0, len(r.samples))
+	samples = append(samples, r.samples[r.next:]...)
+	return append(samples, r.samples[:r.next]...)
+}
+
+// TxVoteHistory returns the number of votes the transaction [txID] received in
+// each of the last VoteHistorySize polls it was processing for, oldest first.
+// Once a transaction stops processing, its history is kept until it is no
+// longer among the last DecidedVoteHistories transactions to stop processing.
+func (ta *Topological) TxVoteHistory(txID ids.ID) []VoteSample {
+	if ring, ok := ta.txVoteHistory[txID.Key()]; ok {
+		return ring.list()
+	}
+	return nil
+}
+
+// recordVoteHistory records the votes of the current poll for each of the
+// processing [txs], and retains the histories of the txs that are no longer
+// processing up to DecidedVoteHistories
+func (ta *Topological) recordVoteHistory(txs map[[32]byte]snowstorm.Tx, votes *ids.Bag) {
+	size := ta.params.VoteHistorySize
+	if size <= 0 {
+		return
+	}
+
+	for key, ring := range ta.txVoteHistory {
+		if _, processing := txs[key]; !processing && !ring.decided {
+			ring.decided = true
+			ta.decidedVoteHistories = append(ta.decidedVoteHistories, key)
+		}
+	}
+	// Forget the oldest decided histories. A history may have started
+	// processing again since it was decided, in which case it is kept.
+	for len(ta.decidedVoteHistories) > ta.params.DecidedVoteHistories {
+		key := ta.decidedVoteHistories[0]
+		ta.decidedVoteHistories = ta.decidedVoteHistories[1:]
+		if ring := ta.txVoteHistory[key]; ring != nil && ring.decided {
+			delete(ta.txVoteHistory, key)
+		}
+	}
+	for key, tx := range txs {
+		ring, ok := ta.txVoteHistory[key]
+		if !ok {
+			ring = &voteRing{}
+			ta.txVoteHistory[key] = ring
+		}
+		ring.decided = false
+		ring.add(VoteSample{
+			Poll:  ta.numPolls,
+			Votes: votes.Count(tx.ID()),
+		}, size)
+	}
+}
@@ -0,0 +1,179 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+)
+
+func TestTxVoteHistory(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 3,
+			Alpha:             2,
+			BetaVirtuous:      10,
+			BetaRogue:         10,
+			ConcurrentRepolls: 1,
+		},
+		Parents:         2,
+		BatchSize:       1,
+		VoteHistorySize: 3,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+
+	if history := ta.TxVoteHistory(tx0.ID()); len(history) != 0 {
+		t.Fatalf("Tx shouldn't have a history before being polled, got %v", history)
+	}
+
+	// Poll i receives i%3 votes for the tx
+	for i := 1; i <= 5; i++ {
+		votes := ids.UniqueBag{}
+		for validator := 0; validator < i%3; validator++ {
+			votes.Add(uint(validator), vtx0.id)
+		}
+		ta.RecordPoll(votes)
+	}
+
+	expected := []VoteSample{
+		{Poll: 3, Votes: 0},
+		{Poll: 4, Votes: 1},
+		{Poll: 5, Votes: 2},
+	}
+	if history := ta.TxVoteHistory(tx0.ID()); !reflect.DeepEqual(history, expected) {
+		t.Fatalf("Wrong vote history. Expected %v got %v", expected, history)
+	} else if tx0.Status() != choices.Processing {
+		t.Fatalf("Tx shouldn't have been decided")
+	}
+
+	if history := ta.TxVoteHistory(GenerateID()); history != nil {
+		t.Fatalf("Unknown tx shouldn't have a history, got %v", history)
+	}
+}
+
+func TestTxVoteHistoryDecided(t *testing.T) {
+	params := Parameters{
+		Parameters: snowball.Parameters{
+			Metrics:           prometheus.NewRegistry(),
+			K:                 1,
+			Alpha:             1,
+			BetaVirtuous:      2,
+			BetaRogue:         2,
+			ConcurrentRepolls: 1,
+		},
+		Parents:              2,
+		BatchSize:            1,
+		VoteHistorySize:      3,
+		DecidedVoteHistories: 1,
+	}
+	vts := []Vertex{&Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}, &Vtx{
+		id:     GenerateID(),
+		status: choices.Accepted,
+	}}
+
+	ta := Topological{}
+	ta.Initialize(snow.DefaultContextTest(), params, vts)
+
+	tx0 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx0.Ins.Add(GenerateID())
+	tx1 := &snowstorm.TestTx{
+		Identifier: GenerateID(),
+		Stat:       choices.Processing,
+	}
+	tx1.Ins.Add(GenerateID())
+
+	vtx0 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx0},
+		height:       1,
+		status:       choices.Processing,
+	}
+	vtx1 := &Vtx{
+		dependencies: vts,
+		id:           GenerateID(),
+		txs:          []snowstorm.Tx{tx1},
+		height:       1,
+		status:       choices.Processing,
+	}
+
+	ta.Add(vtx0)
+	ta.Add(vtx1)
+
+	poll := func(vtx *Vtx) {
+		votes := ids.UniqueBag{}
+		votes.Add(0, vtx.id)
+		ta.RecordPoll(votes)
+	}
+
+	poll(vtx0)
+	poll(vtx0)
+	poll(vtx1)
+
+	expected := []VoteSample{
+		{Poll: 1, Votes: 1},
+		{Poll: 2, Votes: 1},
+	}
+	if tx0.Status() != choices.Accepted {
+		t.Fatalf("Tx should have been accepted")
+	} else if history := ta.TxVoteHistory(tx0.ID()); !reflect.DeepEqual(history, expected) {
+		t.Fatalf("The history of a decided tx should be kept. Expected %v got %v", expected, history)
+	}
+
+	poll(vtx1)
+	poll(vtx1)
+
+	expected = []VoteSample{
+		{Poll: 2, Votes: 0},
+		{Poll: 3, Votes: 1},
+		{Poll: 4, Votes: 1},
+	}
+	if tx1.Status() != choices.Accepted {
+		t.Fatalf("Tx should have been accepted")
+	} else if history := ta.TxVoteHistory(tx1.ID()); !reflect.DeepEqual(history, expected) {
+		t.Fatalf("The history of a decided tx should be kept. Expected %v got %v", expected, history)
+	} else if history := ta.TxVoteHistory(tx0.ID()); history != nil {
+		t.Fatalf("The oldest decided history should have been forgotten, got %v", history)
+	}
+}
@@ -44,10 +44,22 @@ type Consensus interface {
 	// Returns the set of transactions conflicting with <Tx>
 	Conflicts(Tx) ids.Set
 
+	// Returns the current confidence of transaction <Tx>. If <Tx> isn't
+	// processing, 0 is returned.
+	Confidence(Tx) int
+
 	// Collects the results of a network poll. Assumes all transactions
 	// have been previously added
 	RecordPoll(ids.Bag)
 
+	// Rejects the processing transaction with ID <txID> without it being
+	// voted on, and removes it from its conflict sets. This is used for
+	// transactions that were invalidated outside of consensus. If the
+	// transaction was only waiting on its dependencies to be accepted, it
+	// will no longer be accepted. Returns an error if the transaction isn't
+	// processing.
+	Remove(txID ids.ID) error
+
 	// Returns true iff all remaining transactions are rogue. Note, it is
 	// possible that after returning quiesce, a new decision may be added such
 	// that this instance should no longer quiesce.
@@ -610,6 +610,106 @@ func ConflictsTest(t *testing.T, factory Factory) {
 	}
 }
 
+func ConfidenceTest(t *testing.T, factory Factory) {
+	Setup()
+
+	graph := factory.New()
+
+	params := snowball.Parameters{
+		Metrics: prometheus.NewRegistry(),
+		K:       2, Alpha: 2, BetaVirtuous: 3, BetaRogue: 3,
+	}
+	graph.Initialize(snow.DefaultContextTest(), params)
+	graph.Add(Red)
+	graph.Add(Green)
+
+	if confidence := graph.Confidence(Red); confidence != 0 {
+		t.Fatalf("Wrong confidence. Expected %d got %d", 0, confidence)
+	}
+
+	r := ids.Bag{}
+	r.SetThreshold(2)
+	r.AddCount(Red.ID(), 2)
+	graph.RecordPoll(r)
+	graph.RecordPoll(r)
+
+	if confidence := graph.Confidence(Red); confidence != 2 {
+		t.Fatalf("Wrong confidence. Expected %d got %d", 2, confidence)
+	} else if confidence := graph.Confidence(Green); confidence != 0 {
+		t.Fatalf("Wrong confidence. Expected %d got %d", 0, confidence)
+	} else if confidence := graph.Confidence(Blue); confidence != 0 {
+		t.Fatalf("Wrong confidence. Expected %d got %d", 0, confidence)
+	}
+
+	g := ids.Bag{}
+	g.SetThreshold(2)
+	g.AddCount(Green.ID(), 2)
+	graph.RecordPoll(g)
+
+	if confidence := graph.Confidence(Red); confidence != 0 {
+		t.Fatalf("Wrong confidence. Expected %d got %d", 0, confidence)
+	} else if confidence := graph.Confidence(Green); confidence != 1 {
+		t.Fatalf("Wrong confidence. Expected %d got %d", 1, confidence)
+	}
+}
+
+func RemoveTest(t *testing.T, factory Factory) {
+	Setup()
+
+	graph := factory.New()
+
+	params := snowball.Parameters{
+		Metrics: prometheus.NewRegistry(),
+		K:       2, Alpha: 2, BetaVirtuous: 1, BetaRogue: 2,
+	}
+	graph.Initialize(snow.DefaultContextTest(), params)
+	graph.Add(Red)
+	graph.Add(Green)
+	graph.Add(Alpha)
+
+	if err := graph.Remove(Blue.ID()); err == nil {
+		t.Fatalf("Shouldn't be able to remove a transaction that wasn't added")
+	}
+
+	if err := graph.Remove(Green.ID()); err != nil {
+		t.Fatal(err)
+	} else if Green.Status() != choices.Rejected {
+		t.Fatalf("Removed transaction should have been rejected")
+	} else if prefs := graph.Preferences(); prefs.Len() != 2 || !prefs.Contains(Red.ID()) || !prefs.Contains(Alpha.ID()) {
+		t.Fatalf("Wrong preferences. Expected %s and %s got %s", Red.ID(), Alpha.ID(), prefs)
+	} else if virtuous := graph.Virtuous(); virtuous.Contains(Green.ID()) {
+		t.Fatalf("Removed transaction shouldn't be virtuous")
+	} else if !graph.Issued(Green) {
+		t.Fatalf("Removed transaction should be decided")
+	}
+
+	if err := graph.Remove(Alpha.ID()); err != nil {
+		t.Fatal(err)
+	} else if Alpha.Status() != choices.Rejected {
+		t.Fatalf("Removed transaction should have been rejected")
+	} else if prefs := graph.Preferences(); prefs.Len() != 1 || !prefs.Contains(Red.ID()) {
+		t.Fatalf("Wrong preferences. Expected %s got %s", Red.ID(), prefs)
+	} else if virtuous := graph.Virtuous(); virtuous.Contains(Alpha.ID()) {
+		t.Fatalf("Removed transaction shouldn't be virtuous")
+	}
+
+	if err := graph.Remove(Alpha.ID()); err == nil {
+		t.Fatalf("Shouldn't be able to remove a transaction twice")
+	}
+
+	r := ids.Bag{}
+	r.SetThreshold(2)
+	r.AddCount(Red.ID(), 2)
+	graph.RecordPoll(r)
+	graph.RecordPoll(r)
+
+	if Red.Status() != choices.Accepted {
+		t.Fatalf("Remaining transaction should have been accepted")
+	} else if !graph.Finalized() {
+		t.Fatalf("Finalized too late")
+	}
+}
+
 func VirtuousDependsOnRogueTest(t *testing.T, factory Factory) {
 	Setup()
 
@@ -11,6 +11,7 @@ import (
 
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
 	"github.com/ava-labs/gecko/snow/consensus/snowball"
 	"github.com/ava-labs/gecko/snow/events"
 	"github.com/ava-labs/gecko/utils/formatting"
@@ -117,6 +118,15 @@ func (dg *Directed) Conflicts(tx Tx) ids.Set {
 	return conflicts
 }
 
+// Confidence implements the Consensus interface
+func (dg *Directed) Confidence(tx Tx) int {
+	fn, exists := dg.nodes[tx.ID().Key()]
+	if !exists || fn.lastVote != dg.currentVote {
+		return 0
+	}
+	return fn.confidence
+}
+
 // Add implements the Consensus interface
 func (dg *Directed) Add(tx Tx) {
 	if dg.Issued(tx) {
@@ -247,6 +257,29 @@ func (dg *Directed) RecordPoll(votes ids.Bag) {
 	}
 }
 
+// Remove implements the Consensus interface
+func (dg *Directed) Remove(txID ids.ID) error {
+	fn, exists := dg.nodes[txID.Key()]
+	if !exists {
+		return fmt.Errorf("can't remove tx %s as it isn't processing", txID)
+	}
+
+	// Stop future transactions from conflicting with the removed transaction
+	for _, inputID := range fn.tx.InputIDs().List() {
+		inputKey := inputID.Key()
+		spends := dg.spends[inputKey]
+		spends.Remove(txID)
+		if spends.Len() == 0 {
+			delete(dg.spends, inputKey)
+		}
+	}
+	dg.virtuous.Remove(txID)
+	dg.virtuousVoting.Remove(txID)
+
+	dg.reject(txID)
+	return nil
+}
+
 // Quiesce implements the Consensus interface
 func (dg *Directed) Quiesce() bool {
 	numVirtuous := dg.virtuousVoting.Len()
@@ -396,7 +429,8 @@ func (a *directedAccepter) Abandon(id ids.ID) { a.rejected = true }
 
 func (a *directedAccepter) Update() {
 	// If I was rejected or I am still waiting on dependencies to finish do nothing.
-	if a.rejected || a.deps.Len() != 0 {
+	// If I was removed while waiting on dependencies, I was rejected.
+	if a.rejected || a.deps.Len() != 0 || a.fn.tx.Status() == choices.Rejected {
 		return
 	}
 
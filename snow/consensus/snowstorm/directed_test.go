@@ -25,6 +25,10 @@ func TestDirectedIsVirtuous(t *testing.T) { IsVirtuousTest(t, DirectedFactory{})
 
 func TestDirectedConflicts(t *testing.T) { ConflictsTest(t, DirectedFactory{}) }
 
+func TestDirectedConfidence(t *testing.T) { ConfidenceTest(t, DirectedFactory{}) }
+
+func TestDirectedRemove(t *testing.T) { RemoveTest(t, DirectedFactory{}) }
+
 func TestDirectedQuiesce(t *testing.T) { QuiesceTest(t, DirectedFactory{}) }
 
 func TestDirectedAcceptingDependency(t *testing.T) { AcceptingDependencyTest(t, DirectedFactory{}) }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
 	"github.com/ava-labs/gecko/snow/consensus/snowball"
 	"github.com/ava-labs/gecko/snow/events"
 	"github.com/ava-labs/gecko/utils/formatting"
@@ -186,6 +187,26 @@ func (ig *Input) Conflicts(tx Tx) ids.Set {
 	return conflicts
 }
 
+// Confidence implements the ConflictGraph interface
+func (ig *Input) Confidence(tx Tx) int {
+	id := tx.ID()
+	if _, exists := ig.txs[id.Key()]; !exists {
+		return 0
+	}
+
+	confidence := ig.params.BetaRogue
+	for _, inputID := range tx.InputIDs().List() {
+		input := ig.inputs[inputID.Key()]
+		if input.lastVote != ig.currentVote || !id.Equals(input.color) {
+			return 0
+		}
+		if input.confidence < confidence {
+			confidence = input.confidence
+		}
+	}
+	return confidence
+}
+
 // RecordPoll implements the ConflictGraph interface
 func (ig *Input) RecordPoll(votes ids.Bag) {
 	ig.currentVote++
@@ -376,6 +397,19 @@ func (ig *Input) removeConflict(id ids.ID, inputIDs ...ids.ID) {
 	}
 }
 
+// Remove implements the ConflictGraph interface
+func (ig *Input) Remove(txID ids.ID) error {
+	if _, exists := ig.txs[txID.Key()]; !exists {
+		return fmt.Errorf("can't remove tx %s as it isn't processing", txID)
+	}
+
+	ig.virtuous.Remove(txID)
+	ig.virtuousVoting.Remove(txID)
+
+	ig.reject(txID)
+	return nil
+}
+
 // Quiesce implements the ConflictGraph interface
 func (ig *Input) Quiesce() bool {
 	numVirtuous := ig.virtuousVoting.Len()
@@ -393,29 +427,10 @@ func (ig *Input) Finalized() bool {
 func (ig *Input) String() string {
 	nodes := []tempNode{}
 	for _, tx := range ig.txs {
-		id := tx.tx.ID()
-
-		confidence := ig.params.BetaRogue
-		for _, inputID := range tx.tx.InputIDs().List() {
-			input := ig.inputs[inputID.Key()]
-			if input.lastVote != ig.currentVote {
-				confidence = 0
-				break
-			}
-
-			if input.confidence < confidence {
-				confidence = input.confidence
-			}
-			if !id.Equals(input.color) {
-				confidence = 0
-				break
-			}
-		}
-
 		nodes = append(nodes, tempNode{
-			id:         id,
+			id:         tx.tx.ID(),
 			bias:       tx.bias,
-			confidence: confidence,
+			confidence: ig.Confidence(tx.tx),
 		})
 	}
 	sortTempNodes(nodes)
@@ -458,7 +473,8 @@ func (a *inputAccepter) Fulfill(id ids.ID) {
 func (a *inputAccepter) Abandon(id ids.ID) { a.rejected = true }
 
 func (a *inputAccepter) Update() {
-	if a.rejected || a.deps.Len() != 0 {
+	// If I was removed while waiting on dependencies, I was rejected
+	if a.rejected || a.deps.Len() != 0 || a.tn.tx.Status() == choices.Rejected {
 		return
 	}
 
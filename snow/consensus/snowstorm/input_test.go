@@ -25,6 +25,10 @@ func TestInputIsVirtuous(t *testing.T) { IsVirtuousTest(t, InputFactory{}) }
 
 func TestInputConflicts(t *testing.T) { ConflictsTest(t, InputFactory{}) }
 
+func TestInputConfidence(t *testing.T) { ConfidenceTest(t, InputFactory{}) }
+
+func TestInputRemove(t *testing.T) { RemoveTest(t, InputFactory{}) }
+
 func TestInputQuiesce(t *testing.T) { QuiesceTest(t, InputFactory{}) }
 
 func TestInputAcceptingDependency(t *testing.T) { AcceptingDependencyTest(t, InputFactory{}) }
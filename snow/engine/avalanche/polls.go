@@ -42,6 +42,7 @@ func (p *polls) Add(requestID uint32, numPolled int) bool {
 	poll, exists := p.m[requestID]
 	if !exists {
 		poll.numPending = numPolled
+		poll.voters = make([]ids.ShortID, numPolled)
 		p.m[requestID] = poll
 
 		p.numPolls.Set(float64(len(p.m))) // Tracks performance statistics
@@ -50,24 +51,26 @@ func (p *polls) Add(requestID uint32, numPolled int) bool {
 }
 
 // Vote registers the connections response to a query for [id]. If there was no
-// query, or the response has already be registered, nothing is performed.
-func (p *polls) Vote(requestID uint32, vdr ids.ShortID, votes []ids.ID) (ids.UniqueBag, bool) {
+// query, or the response has already be registered, nothing is performed. Once
+// the poll finishes, its votes are returned along with the validator whose
+// votes were recorded with each index.
+func (p *polls) Vote(requestID uint32, vdr ids.ShortID, votes []ids.ID) (ids.UniqueBag, []ids.ShortID, bool) {
 	p.log.Verbo("Vote. requestID: %d. validatorID: %s.", requestID, vdr)
 	poll, exists := p.m[requestID]
 	p.log.Verbo("Poll: %+v", poll)
 	if !exists {
-		return nil, false
+		return nil, nil, false
 	}
 
-	poll.Vote(votes)
+	poll.Vote(vdr, votes)
 	if poll.Finished() {
 		p.log.Verbo("Poll is finished")
 		delete(p.m, requestID)
 		p.numPolls.Set(float64(len(p.m))) // Tracks performance statistics
-		return poll.votes, true
+		return poll.votes, poll.voters, true
 	}
 	p.m[requestID] = poll
-	return nil, false
+	return nil, nil, false
 }
 
 func (p *polls) String() string {
@@ -83,14 +86,17 @@ func (p *polls) String() string {
 
 // poll represents the current state of a network poll for a vertex
 type poll struct {
-	votes      ids.UniqueBag
+	votes ids.UniqueBag
+	// voters[i] is the validator whose votes were recorded with index i
+	voters     []ids.ShortID
 	numPending int
 }
 
 // Vote registers a vote for this poll
-func (p *poll) Vote(votes []ids.ID) {
+func (p *poll) Vote(vdr ids.ShortID, votes []ids.ID) {
 	if p.numPending > 0 {
 		p.numPending--
+		p.voters[p.numPending] = vdr
 		p.votes.Add(uint(p.numPending), votes...)
 	}
 }
@@ -58,7 +58,12 @@ func (t *Transitive) finishBootstrapping() {
 			t.Config.Context.Log.Error("Vertex %s failed to be loaded from the frontier with %s", vtxID, err)
 		}
 	}
-	t.Consensus.Initialize(t.Config.Context, t.Params, frontier)
+	if err := t.Consensus.Initialize(t.Config.Context, t.Params, frontier); err != nil {
+		// A half-configured instance can't safely run consensus, so the engine
+		// continues to drop consensus messages
+		t.Config.Context.Log.Fatal("Avalanche consensus failed to initialize with %s", err)
+		return
+	}
 	t.bootstrapped = true
 }
 
@@ -17,6 +17,7 @@ import (
 	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
 	"github.com/ava-labs/gecko/snow/engine/common"
 	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils/logging"
 )
 
 var (
@@ -112,6 +113,26 @@ func TestEngineAdd(t *testing.T) {
 	}
 }
 
+func TestEngineInitializeError(t *testing.T) {
+	config := DefaultConfig()
+	config.Params.Parents = 1
+
+	st := &stateTest{t: t}
+	config.State = st
+
+	st.Default(true)
+
+	st.cantEdge = false
+
+	te := &Transitive{}
+	te.Initialize(config)
+	te.finishBootstrapping()
+
+	if te.bootstrapped {
+		t.Fatalf("Shouldn't have finished bootstrapping with invalid parameters")
+	}
+}
+
 func TestEngineQuery(t *testing.T) {
 	config := DefaultConfig()
 
@@ -2548,3 +2569,42 @@ func TestEnginePartiallyValidVertex(t *testing.T) {
 
 	te.insert(vtx)
 }
+
+func TestPollsVoters(t *testing.T) {
+	p := polls{
+		log:      logging.NoLog{},
+		numPolls: prometheus.NewGauge(prometheus.GaugeOpts{}),
+		m:        make(map[uint32]poll),
+	}
+
+	vdr0 := ids.NewShortID([20]byte{1})
+	vdr1 := ids.NewShortID([20]byte{2})
+	vtxID0 := GenerateID()
+	vtxID1 := GenerateID()
+
+	if !p.Add(1, 2) {
+		t.Fatalf("Should have added the poll")
+	}
+	if _, _, finished := p.Vote(1, vdr0, []ids.ID{vtxID0}); finished {
+		t.Fatalf("Poll shouldn't have finished after one response")
+	}
+	votes, voters, finished := p.Vote(1, vdr1, []ids.ID{vtxID1})
+	if !finished {
+		t.Fatalf("Poll should have finished")
+	}
+	if len(voters) != 2 {
+		t.Fatalf("Expected %d voters, got %d", 2, len(voters))
+	}
+
+	// Each vote must be attributed to the validator that sent it
+	for i, vdr := range voters {
+		switch {
+		case vdr.Equals(vdr0) && !votes.GetSet(vtxID0).Contains(uint(i)):
+			t.Fatalf("Validator 0 should have voted for vertex 0 with index %d", i)
+		case vdr.Equals(vdr1) && !votes.GetSet(vtxID1).Contains(uint(i)):
+			t.Fatalf("Validator 1 should have voted for vertex 1 with index %d", i)
+		case !vdr.Equals(vdr0) && !vdr.Equals(vdr1):
+			t.Fatalf("Unexpected voter %s", vdr)
+		}
+	}
+}
@@ -31,14 +31,14 @@ func (v *voter) Update() {
 		return
 	}
 
-	results, finished := v.t.polls.Vote(v.requestID, v.vdr, v.response.List())
+	results, voters, finished := v.t.polls.Vote(v.requestID, v.vdr, v.response.List())
 	if !finished {
 		return
 	}
 	results = v.bubbleVotes(results)
 
 	v.t.Config.Context.Log.Debug("Finishing poll with:\n%s", &results)
-	v.t.Consensus.RecordPoll(results)
+	v.t.Consensus.RecordPollFrom(results, voters)
 
 	txs := []snowstorm.Tx(nil)
 	for _, orphanID := range v.t.Consensus.Orphans().List() {